@@ -0,0 +1,447 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const deletionTokenTTL = 15 * time.Minute
+
+// UserExport to pełny zrzut danych usera (export.json w archiwum).
+type UserExport struct {
+	ExportedAt    time.Time               `json:"exportedAt"`
+	Profile       MeResponse              `json:"profile"`
+	CreatedAt     time.Time               `json:"createdAt"`
+	Exams         []Exam                  `json:"exams"`
+	ExamQuestions []ExamQuestion          `json:"examQuestions"`
+	Answers       []Answer                `json:"answers"`
+	Groups        []GroupMembershipExport `json:"groupMemberships"`
+	Comments      []Comment               `json:"comments"`
+	Votes         []CommentVote           `json:"commentVotes"`
+	Reports       []QuestionReport        `json:"questionReports"`
+	Proposals     []ProposalExport        `json:"proposals"`
+	Stats         *StatsResponse          `json:"stats"`
+}
+
+// GroupMembershipExport to członkostwo usera w grupie (z nazwą grupy).
+type GroupMembershipExport struct {
+	GroupID   uint      `json:"groupId"`
+	GroupName string    `json:"groupName"`
+	Role      string    `json:"role"`
+	Owner     bool      `json:"owner"`
+	JoinedAt  time.Time `json:"joinedAt"`
+}
+
+// ProposalExport to propozycja razem z treścią pytania (Payload jest ukryty w API).
+type ProposalExport struct {
+	QuestionProposal
+	Payload json.RawMessage `json:"payload"`
+}
+
+func loadUserExport(db *gorm.DB, u *User) (*UserExport, error) {
+	out := &UserExport{
+		ExportedAt: time.Now(),
+		Profile:    toMeResponse(*u),
+		CreatedAt:  u.CreatedAt,
+	}
+	if err := db.Where("user_id = ?", u.ID).Order("started_at").Find(&out.Exams).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(out.Exams))
+	for _, e := range out.Exams {
+		ids = append(ids, e.ID)
+	}
+	if len(ids) > 0 {
+		if err := db.Where("exam_id IN ?", ids).Order("exam_id, position").Find(&out.ExamQuestions).Error; err != nil {
+			return nil, err
+		}
+		if err := db.Where("exam_id IN ?", ids).Order("answered_at").Find(&out.Answers).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := db.Table("group_members m").
+		Select("m.group_id as group_id, g.name as group_name, m.role as role, g.owner_id = m.user_id as owner, m.joined_at as joined_at").
+		Joins("JOIN groups g ON g.id = m.group_id").
+		Where("m.user_id = ?", u.ID).Order("m.joined_at").
+		Scan(&out.Groups).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", u.ID).Order("created_at").Find(&out.Comments).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", u.ID).Order("created_at").Find(&out.Votes).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", u.ID).Order("created_at").Find(&out.Reports).Error; err != nil {
+		return nil, err
+	}
+	var proposals []QuestionProposal
+	if err := db.Where("author_id = ?", u.ID).Order("created_at").Find(&proposals).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range proposals {
+		out.Proposals = append(out.Proposals, ProposalExport{QuestionProposal: p, Payload: json.RawMessage(p.Payload)})
+	}
+	stats, err := computeStats(db, u.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	out.Stats = stats
+	return out, nil
+}
+
+// writeExportZip pakuje eksport jako export.json + pliki CSV per tabela.
+func writeExportZip(ex *UserExport) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	jw, err := zw.Create("export.json")
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(jw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(ex); err != nil {
+		return nil, err
+	}
+
+	fmtTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	fmtFloat := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f, 'f', 2, 64)
+	}
+
+	exams := [][]string{{"id", "type", "started_at", "finished_at", "duration_seconds", "score_percent"}}
+	for _, e := range ex.Exams {
+		exams = append(exams, []string{
+			e.ID, e.Type, fmtTime(&e.StartedAt), fmtTime(e.FinishedAt),
+			strconv.Itoa(e.DurationSeconds), fmtFloat(e.ScorePercent),
+		})
+	}
+	eqs := [][]string{{"exam_id", "question_id", "position"}}
+	for _, q := range ex.ExamQuestions {
+		eqs = append(eqs, []string{q.ExamID, q.QuestionID, strconv.Itoa(q.Position)})
+	}
+	answers := [][]string{{"exam_id", "question_id", "selected", "is_correct", "answered_at"}}
+	for _, a := range ex.Answers {
+		answers = append(answers, []string{
+			a.ExamID, a.QuestionID, a.SelectedRaw, strconv.FormatBool(a.IsCorrect), fmtTime(&a.AnsweredAt),
+		})
+	}
+
+	comments := [][]string{{"id", "question_id", "parent_id", "body", "upvotes", "accepted", "deleted", "created_at"}}
+	for _, cm := range ex.Comments {
+		parent := ""
+		if cm.ParentID != nil {
+			parent = strconv.FormatUint(uint64(*cm.ParentID), 10)
+		}
+		comments = append(comments, []string{
+			strconv.FormatUint(uint64(cm.ID), 10), cm.QuestionID, parent, cm.Body, strconv.Itoa(cm.Upvotes),
+			strconv.FormatBool(cm.Accepted), strconv.FormatBool(cm.Deleted), fmtTime(&cm.CreatedAt),
+		})
+	}
+	groups := [][]string{{"group_id", "group_name", "role", "owner", "joined_at"}}
+	for _, g := range ex.Groups {
+		groups = append(groups, []string{
+			strconv.FormatUint(uint64(g.GroupID), 10), g.GroupName, g.Role, strconv.FormatBool(g.Owner), fmtTime(&g.JoinedAt),
+		})
+	}
+	votes := [][]string{{"comment_id", "created_at"}}
+	for _, v := range ex.Votes {
+		votes = append(votes, []string{strconv.FormatUint(uint64(v.CommentID), 10), fmtTime(&v.CreatedAt)})
+	}
+	reports := [][]string{{"id", "question_id", "category", "comment", "status", "created_at"}}
+	for _, r := range ex.Reports {
+		reports = append(reports, []string{
+			strconv.FormatUint(uint64(r.ID), 10), r.QuestionID, r.Category, r.Comment, r.Status, fmtTime(&r.CreatedAt),
+		})
+	}
+	proposals := [][]string{{"id", "bank", "status", "created_at", "payload"}}
+	for _, p := range ex.Proposals {
+		proposals = append(proposals, []string{
+			strconv.FormatUint(uint64(p.ID), 10), p.BankID, p.Status, fmtTime(&p.CreatedAt), string(p.Payload),
+		})
+	}
+
+	files := []struct {
+		name string
+		rows [][]string
+	}{
+		{"exams.csv", exams},
+		{"exam_questions.csv", eqs},
+		{"answers.csv", answers},
+		{"group_memberships.csv", groups},
+		{"comments.csv", comments},
+		{"comment_votes.csv", votes},
+		{"question_reports.csv", reports},
+		{"proposals.csv", proposals},
+	}
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range f.rows[1:] {
+			for i := range row {
+				row[i] = csvSafe(row[i])
+			}
+		}
+		if err := csv.NewWriter(w).WriteAll(f.rows); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// csvSafe chroni komórkę przed wstrzyknięciem formuły w arkuszu ('=', '+', '-', '@' na początku).
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// GET /api/v1/me/export
+func ExportMyData(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		var u User
+		if err := db.First(&u, uid).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+			return
+		}
+		ex, err := loadUserExport(db, &u)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		data, err := writeExportZip(ex)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "export failed"})
+			return
+		}
+		filename := fmt.Sprintf("sap-quiz-export-%s.zip", ex.ExportedAt.Format("2006-01-02"))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Data(http.StatusOK, "application/zip", data)
+	}
+}
+
+// POST /api/v1/me/deletion-token
+// Pierwszy krok usunięcia konta: wydaje krótko ważny token potwierdzający.
+func RequestDeletion(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		tok := DeletionToken{UserID: uid, Token: uuid.New().String(), ExpiresAt: time.Now().Add(deletionTokenTTL)}
+		// jeden aktywny token na usera — nowy zastępuje poprzedni
+		if err := db.Where("user_id = ?", uid).Delete(&DeletionToken{}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		if err := db.Create(&tok).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"confirmToken": tok.Token, "expiresAt": tok.ExpiresAt})
+	}
+}
+
+var errSoleTrainer = errors.New("sole trainer")
+
+// releaseGroups odpina usera od grup przed usunięciem konta: grupy, w których był
+// jedyną osobą, znikają (z zadaniami); własność grupy przechodzi na najstarszego innego
+// trenera, a bez trenera na najstarszego członka. errSoleTrainer, gdy jest jedynym
+// trenerem grupy z innymi członkami.
+func releaseGroups(tx *gorm.DB, uid uint) error {
+	var ms []GroupMember
+	if err := tx.Where("user_id = ?", uid).Find(&ms).Error; err != nil {
+		return err
+	}
+	for _, m := range ms {
+		var members, trainers int64
+		if err := tx.Model(&GroupMember{}).Where("group_id = ?", m.GroupID).Count(&members).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&GroupMember{}).Where("group_id = ? AND role = ?", m.GroupID, GroupRoleTrainer).Count(&trainers).Error; err != nil {
+			return err
+		}
+		if members == 1 {
+			if err := deleteGroup(tx, m.GroupID); err != nil {
+				return err
+			}
+			continue
+		}
+		if m.Role == GroupRoleTrainer && trainers == 1 {
+			return errSoleTrainer
+		}
+	}
+	// grupy, których user jest właścicielem (także po wyjściu z nich)
+	var owned []Group
+	if err := tx.Where("owner_id = ?", uid).Find(&owned).Error; err != nil {
+		return err
+	}
+	for _, g := range owned {
+		var next []GroupMember
+		if err := tx.Where("group_id = ? AND user_id <> ?", g.ID, uid).
+			Order(fmt.Sprintf("CASE WHEN role = '%s' THEN 0 ELSE 1 END, joined_at", GroupRoleTrainer)).
+			Limit(1).Find(&next).Error; err != nil {
+			return err
+		}
+		if len(next) == 0 { // nikt nie został
+			if err := deleteGroup(tx, g.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tx.Model(&g).Update("owner_id", next[0].UserID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteUserData usuwa usera i wszystkie jego dane. Przy anonymize egzaminy
+// zostają (bez user_id), żeby nie psuć zagregowanych statystyk pytań.
+// Jedyny trener grupy z członkami musi najpierw awansować następcę (errSoleTrainer).
+func deleteUserData(tx *gorm.DB, uid uint, anonymize bool) error {
+	if err := releaseGroups(tx, uid); err != nil {
+		return err
+	}
+	if anonymize {
+		if err := tx.Model(&Exam{}).Where("user_id = ?", uid).Update("user_id", nil).Error; err != nil {
+			return err
+		}
+	} else {
+		var ids []string
+		if err := tx.Model(&Exam{}).Where("user_id = ?", uid).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			if err := tx.Where("exam_id IN ?", ids).Delete(&Answer{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("exam_id IN ?", ids).Delete(&ExamQuestion{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("exam_id IN ?", ids).Delete(&ExamPause{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", ids).Delete(&Exam{}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Where("target_user_id = ?", uid).Delete(&AccountMerge{}).Error; err != nil {
+		return err
+	}
+	// komentarze: soft delete (wątki innych zostają), głosy usera znikają z liczników
+	if err := tx.Model(&Comment{}).Where("user_id = ?", uid).
+		Updates(map[string]interface{}{"deleted": true, "body": "", "accepted": false}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&Comment{}).
+		Where("id IN (?)", tx.Model(&CommentVote{}).Select("comment_id").Where("user_id = ?", uid)).
+		Update("upvotes", gorm.Expr("upvotes - 1")).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&CommentVote{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&QuestionReport{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&GroupMember{}).Error; err != nil {
+		return err
+	}
+	// nieopublikowane propozycje znikają; opublikowane pytania zostają bez atrybucji
+	if err := tx.Where("author_id = ?", uid).Delete(&QuestionProposal{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&Question{}).Where("author_id = ?", uid).Update("author_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&LeaderboardEntry{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", uid).Delete(&DeletionToken{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&User{}, uid).Error
+}
+
+// DELETE /api/v1/me?token=...&anonymize=true
+// Drugi krok: usuwa konto po podaniu tokenu z /me/deletion-token i czyści cookie.
+func DeleteMe(db *gorm.DB, secureCookies bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		token := c.Query("token")
+		if token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "token required (POST /api/v1/me/deletion-token)"})
+			return
+		}
+		anonymize := c.Query("anonymize") == "true"
+
+		var tok DeletionToken
+		if err := db.First(&tok, "user_id = ? AND token = ?", uid, token).Error; err != nil || time.Now().After(tok.ExpiresAt) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "invalid or expired token"})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return deleteUserData(tx, uid, anonymize)
+		})
+		if errors.Is(err, errSoleTrainer) {
+			c.JSON(http.StatusConflict, gin.H{"error": "promote another trainer in your groups before deleting the account"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     cookieName,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   secureCookies,
+			SameSite: http.SameSiteLaxMode,
+		})
+		c.JSON(http.StatusOK, gin.H{"status": "deleted", "examsAnonymized": anonymize})
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func seedUserWithExam(t *testing.T, db *gorm.DB, pubID, examID string) User {
+	t.Helper()
+	u := User{PublicID: pubID}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	e := Exam{ID: examID, UserID: &u.ID, Type: "exam", DurationSeconds: 60}
+	if err := db.Create(&e).Error; err != nil {
+		t.Fatalf("create exam: %v", err)
+	}
+	if err := db.Create(&ExamQuestion{ExamID: examID, QuestionID: "001", Position: 1}).Error; err != nil {
+		t.Fatalf("create exam question: %v", err)
+	}
+	if err := db.Create(&Answer{ExamID: examID, QuestionID: "001", SelectedRaw: `["a"]`}).Error; err != nil {
+		t.Fatalf("create answer: %v", err)
+	}
+	return u
+}
+
+func TestDeleteUserData(t *testing.T) {
+	db := newTestDB(t)
+	erased := seedUserWithExam(t, db, "erase", "e1")
+	anon := seedUserWithExam(t, db, "anon", "e2")
+
+	if err := deleteUserData(db, erased.ID, false); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := deleteUserData(db, anon.ID, true); err != nil {
+		t.Fatalf("delete anonymized: %v", err)
+	}
+
+	var users, exams, answers int64
+	db.Model(&User{}).Count(&users)
+	db.Model(&Exam{}).Count(&exams)
+	db.Model(&Answer{}).Count(&answers)
+	if users != 0 {
+		t.Errorf("users = %d, want 0", users)
+	}
+	// e1 usunięty razem z odpowiedziami, e2 zostaje bez właściciela
+	if exams != 1 || answers != 1 {
+		t.Errorf("exams = %d, answers = %d, want 1 and 1", exams, answers)
+	}
+	var e Exam
+	if err := db.First(&e, "id = ?", "e2").Error; err != nil {
+		t.Fatalf("anonymized exam missing: %v", err)
+	}
+	if e.UserID != nil {
+		t.Errorf("anonymized exam still has user_id %d", *e.UserID)
+	}
+}
+
+func TestDeleteUserDataReleasesGroups(t *testing.T) {
+	db := newTestDB(t)
+	var trainer, coTrainer, member User
+	for i, u := range []*User{&trainer, &coTrainer, &member} {
+		u.PublicID = fmt.Sprintf("u%d", i)
+		db.Create(u)
+	}
+	now := time.Now()
+	join := func(g *Group, u User, role string) {
+		db.Create(&GroupMember{GroupID: g.ID, UserID: u.ID, Role: role, JoinedAt: now})
+	}
+	solo := Group{Name: "solo", InviteCode: "SOLO", OwnerID: trainer.ID}
+	shared := Group{Name: "shared", InviteCode: "SHARED", OwnerID: trainer.ID}
+	sole := Group{Name: "sole", InviteCode: "SOLE", OwnerID: trainer.ID}
+	for _, g := range []*Group{&solo, &shared, &sole} {
+		db.Create(g)
+		join(g, trainer, GroupRoleTrainer)
+	}
+	join(&shared, coTrainer, GroupRoleTrainer)
+	join(&shared, member, GroupRoleMember)
+	join(&sole, member, GroupRoleMember)
+	// grupa, z której trener już wyszedł: został tylko zwykły członek
+	left := Group{Name: "left", InviteCode: "LEFT", OwnerID: trainer.ID}
+	db.Create(&left)
+	join(&left, member, GroupRoleMember)
+	task := Assignment{GroupID: solo.ID, CreatedBy: trainer.ID, Title: "t", OpensAt: now, ClosesAt: now.Add(time.Hour), DurationSeconds: 60}
+	db.Create(&task)
+	db.Create(&AssignmentQuestion{AssignmentID: task.ID, QuestionID: "001", Position: 1})
+
+	if err := deleteUserData(db, trainer.ID, false); !errors.Is(err, errSoleTrainer) {
+		t.Fatalf("sole trainer of a group with members: err = %v, want errSoleTrainer", err)
+	}
+
+	db.Model(&GroupMember{}).Where("group_id = ? AND user_id = ?", sole.ID, member.ID).Update("role", GroupRoleTrainer)
+	if err := deleteUserData(db, trainer.ID, false); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := db.First(&Group{}, solo.ID).Error; err == nil {
+		t.Error("group with no one left was kept")
+	}
+	var tasks, taskQuestions int64
+	db.Model(&Assignment{}).Count(&tasks)
+	db.Model(&AssignmentQuestion{}).Count(&taskQuestions)
+	if tasks != 0 || taskQuestions != 0 {
+		t.Errorf("deleted group left %d assignments and %d assignment questions", tasks, taskQuestions)
+	}
+	for g, want := range map[*Group]uint{&shared: coTrainer.ID, &sole: member.ID, &left: member.ID} {
+		var got Group
+		db.First(&got, g.ID)
+		if got.OwnerID != want {
+			t.Errorf("%s: owner %d, want %d", g.Name, got.OwnerID, want)
+		}
+	}
+}
+
+func TestUserExportIncludesContributions(t *testing.T) {
+	db := newTestDB(t)
+	u := seedUserWithExam(t, db, "me", "e1")
+	other := User{PublicID: "other"}
+	db.Create(&other)
+	db.Create(&Comment{QuestionID: "001", UserID: u.ID, Body: "=HYPERLINK(\"http://x\")"})
+	theirs := Comment{QuestionID: "001", UserID: other.ID, Body: "theirs"}
+	db.Create(&theirs)
+	db.Create(&CommentVote{CommentID: theirs.ID, UserID: u.ID})
+	g := Group{Name: "cohort", InviteCode: "C1", OwnerID: other.ID}
+	db.Create(&g)
+	db.Create(&GroupMember{GroupID: g.ID, UserID: u.ID, Role: GroupRoleMember, JoinedAt: time.Now()})
+	db.Create(&QuestionReport{QuestionID: "001", UserID: u.ID, Category: "typo", Status: "open"})
+	db.Create(&QuestionProposal{AuthorID: u.ID, BankID: "cx", Payload: `{"questionText":"new"}`, Status: "draft"})
+
+	ex, err := loadUserExport(db, &u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ex.Comments) != 1 || ex.Comments[0].UserID != u.ID || len(ex.Reports) != 1 || len(ex.Proposals) != 1 {
+		t.Fatalf("comments %v, reports %v, proposals %v", ex.Comments, ex.Reports, ex.Proposals)
+	}
+	if len(ex.Votes) != 1 || len(ex.Groups) != 1 || ex.Groups[0].GroupName != "cohort" || ex.Groups[0].Owner {
+		t.Fatalf("votes %v, groups %+v", ex.Votes, ex.Groups)
+	}
+	data, err := writeExportZip(ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]bool{}
+	for _, f := range zr.File {
+		files[f.Name] = true
+		if f.Name != "comments.csv" {
+			continue
+		}
+		rc, _ := f.Open()
+		rows, err := csv.NewReader(rc).ReadAll()
+		rc.Close()
+		if err != nil || len(rows) != 2 || !strings.HasPrefix(rows[1][3], "'=") {
+			t.Errorf("comments.csv rows %q, want the formula prefixed with '", rows)
+		}
+	}
+	for _, name := range []string{"group_memberships.csv", "comments.csv", "comment_votes.csv", "question_reports.csv", "proposals.csv"} {
+		if !files[name] {
+			t.Errorf("%s missing from the archive", name)
+		}
+	}
+	raw, _ := json.Marshal(ex)
+	if !strings.Contains(string(raw), `"questionText":"new"`) {
+		t.Error("proposal payload missing from export.json")
+	}
+}
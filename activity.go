@@ -0,0 +1,250 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // strefy czasowe także w obrazie bez /usr/share/zoneinfo
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	dateLayout        = "2006-01-02"
+	activityMaxDays   = 2 * 366 // maksymalny zakres from..to
+	activityHeatLevel = 4       // poziomy intensywności heatmapy 1..4 (0 = brak)
+)
+
+type ActivityBucket struct {
+	Start    string   `json:"start"` // pierwszy dzień kubełka (YYYY-MM-DD, lokalnie)
+	Answers  int      `json:"answers"`
+	Correct  int      `json:"correct"`
+	Accuracy *float64 `json:"accuracy,omitempty"`
+}
+
+type HeatmapDay struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+	Level int    `json:"level"` // 0..4
+}
+
+type Streaks struct {
+	Current      int    `json:"current"` // dni z rzędu do dziś (albo wczoraj, gdy dziś jeszcze nic)
+	Longest      int    `json:"longest"`
+	LastActiveOn string `json:"lastActiveOn,omitempty"`
+}
+
+type ActivityResponse struct {
+	From     string           `json:"from"`
+	To       string           `json:"to"`
+	Bucket   string           `json:"bucket"`
+	Timezone string           `json:"timezone"`
+	Buckets  []ActivityBucket `json:"buckets"`
+	Streaks  Streaks          `json:"streaks"`
+	Heatmap  []HeatmapDay     `json:"heatmap"`
+}
+
+type activityAnswer struct {
+	AnsweredAt time.Time
+	IsCorrect  bool
+}
+
+// localDay obcina czas do północy w strefie loc.
+func localDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// bucketStart zwraca początek kubełka: dzień albo poniedziałek tygodnia (ISO).
+func bucketStart(day time.Time, bucket string) time.Time {
+	if bucket == "week" {
+		offset := (int(day.Weekday()) + 6) % 7 // pon=0 .. nd=6
+		return day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// bucketActivity grupuje odpowiedzi z zakresu [from, to] (dni lokalne) w ciągłą serię
+// kubełków (także pustych) i dzienną heatmapę.
+func bucketActivity(answers []activityAnswer, from, to time.Time, bucket string, loc *time.Location) ([]ActivityBucket, []HeatmapDay) {
+	perBucket := map[string]*ActivityBucket{}
+	perDay := map[string]int{}
+	for _, a := range answers {
+		day := localDay(a.AnsweredAt, loc)
+		if day.Before(from) || day.After(to) {
+			continue
+		}
+		perDay[day.Format(dateLayout)]++
+		key := bucketStart(day, bucket).Format(dateLayout)
+		b := perBucket[key]
+		if b == nil {
+			b = &ActivityBucket{Start: key}
+			perBucket[key] = b
+		}
+		b.Answers++
+		if a.IsCorrect {
+			b.Correct++
+		}
+	}
+
+	buckets := []ActivityBucket{}
+	step := 1
+	if bucket == "week" {
+		step = 7
+	}
+	for d := bucketStart(from, bucket); !d.After(to); d = d.AddDate(0, 0, step) {
+		key := d.Format(dateLayout)
+		b := ActivityBucket{Start: key}
+		if pb := perBucket[key]; pb != nil {
+			b = *pb
+			b.Accuracy = answerCount{Total: int64(b.Answers), Correct: int64(b.Correct)}.Accuracy()
+		}
+		buckets = append(buckets, b)
+	}
+
+	max := 0
+	for _, n := range perDay {
+		if n > max {
+			max = n
+		}
+	}
+	heatmap := []HeatmapDay{}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		key := d.Format(dateLayout)
+		h := HeatmapDay{Date: key, Count: perDay[key]}
+		if h.Count > 0 {
+			// poziom 1..4 proporcjonalnie do najaktywniejszego dnia w zakresie
+			h.Level = (h.Count*activityHeatLevel + max - 1) / max
+		}
+		heatmap = append(heatmap, h)
+	}
+	return buckets, heatmap
+}
+
+// computeStreaks liczy serie dni z co najmniej jedną odpowiedzią.
+// days to dni lokalne (YYYY-MM-DD), w dowolnej kolejności, mogą się powtarzać.
+func computeStreaks(days []string, today time.Time) Streaks {
+	var s Streaks
+	if len(days) == 0 {
+		return s
+	}
+	sorted := append([]string(nil), days...)
+	sort.Strings(sorted)
+
+	run := 0
+	var prev time.Time
+	for i, d := range sorted {
+		if i > 0 && d == sorted[i-1] {
+			continue
+		}
+		t, err := time.ParseInLocation(dateLayout, d, today.Location())
+		if err != nil {
+			continue
+		}
+		if run > 0 && prev.AddDate(0, 0, 1).Equal(t) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > s.Longest {
+			s.Longest = run
+		}
+		prev = t
+	}
+	s.LastActiveOn = prev.Format(dateLayout)
+
+	// bieżąca seria trwa, jeśli ostatni aktywny dzień to dziś albo wczoraj
+	todayDay := localDay(today, today.Location())
+	if prev.Equal(todayDay) || prev.Equal(todayDay.AddDate(0, 0, -1)) {
+		s.Current = run
+	}
+	return s
+}
+
+// activityLocation: ?tz= ma pierwszeństwo, potem strefa zapisana w profilu, na końcu UTC.
+func activityLocation(db *gorm.DB, uid uint, tz string) (*time.Location, error) {
+	if tz == "" {
+		var u User
+		if err := db.Select("timezone").First(&u, uid).Error; err == nil && u.Timezone != nil {
+			tz = *u.Timezone
+		}
+	}
+	if tz == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+// GET /api/v1/stats/activity?from=2025-01-01&to=2025-03-31&bucket=day|week&tz=Europe/Warsaw&bank=
+// from/to to dni lokalne (włącznie); domyślnie ostatnie 90 dni.
+func Activity(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		bucket := c.DefaultQuery("bucket", "day")
+		if bucket != "day" && bucket != "week" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bucket must be day|week"})
+			return
+		}
+		loc, err := activityLocation(db, uid, strings.TrimSpace(c.Query("tz")))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown timezone"})
+			return
+		}
+
+		now := time.Now().In(loc)
+		to := localDay(now, loc)
+		if v := c.Query("to"); v != "" {
+			if to, err = time.ParseInLocation(dateLayout, v, loc); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+				return
+			}
+		}
+		from := to.AddDate(0, 0, -89)
+		if v := c.Query("from"); v != "" {
+			if from, err = time.ParseInLocation(dateLayout, v, loc); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+				return
+			}
+		}
+		if from.After(to) || to.Sub(from) > activityMaxDays*24*time.Hour {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to, max range is 2 years"})
+			return
+		}
+
+		// wszystkie odpowiedzi usera: serie liczymy z całej historii, kubełki tylko z zakresu
+		tx := db.Table("answers a").
+			Select("a.answered_at as answered_at, a.is_correct as is_correct").
+			Joins("JOIN exams e ON e.id = a.exam_id").
+			Where("e.user_id = ? AND e.status <> ?", uid, ExamStatusAbandoned) // jak w /stats
+		if bank := c.Query("bank"); bank != "" {
+			tx = tx.Where("e.bank_id = ?", bank)
+		}
+		var answers []activityAnswer
+		if err := tx.Scan(&answers).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+
+		buckets, heatmap := bucketActivity(answers, from, to, bucket, loc)
+		days := make([]string, 0, len(answers))
+		for _, a := range answers {
+			days = append(days, localDay(a.AnsweredAt, loc).Format(dateLayout))
+		}
+
+		c.JSON(http.StatusOK, ActivityResponse{
+			From:     from.Format(dateLayout),
+			To:       to.Format(dateLayout),
+			Bucket:   bucket,
+			Timezone: loc.String(),
+			Buckets:  buckets,
+			Streaks:  computeStreaks(days, now),
+			Heatmap:  heatmap,
+		})
+	}
+}
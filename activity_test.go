@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketActivityTimezone(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 23:30 UTC 1 marca to już 2 marca w Warszawie
+	answers := []activityAnswer{
+		{AnsweredAt: time.Date(2025, 3, 1, 23, 30, 0, 0, time.UTC), IsCorrect: true},
+		{AnsweredAt: time.Date(2025, 3, 2, 10, 0, 0, 0, time.UTC), IsCorrect: false},
+		{AnsweredAt: time.Date(2025, 3, 20, 10, 0, 0, 0, time.UTC), IsCorrect: true}, // poza zakresem
+	}
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, warsaw)
+	to := time.Date(2025, 3, 3, 0, 0, 0, 0, warsaw)
+
+	buckets, heatmap := bucketActivity(answers, from, to, "day", warsaw)
+	if len(buckets) != 3 || len(heatmap) != 3 {
+		t.Fatalf("got %d buckets, %d heatmap days, want 3 each", len(buckets), len(heatmap))
+	}
+	if buckets[0].Answers != 0 || buckets[1].Answers != 2 || buckets[1].Correct != 1 {
+		t.Errorf("buckets = %+v", buckets)
+	}
+	if buckets[1].Accuracy == nil || *buckets[1].Accuracy != 50 || buckets[0].Accuracy != nil {
+		t.Errorf("accuracy: %+v", buckets)
+	}
+	if heatmap[1].Level != activityHeatLevel || heatmap[0].Level != 0 {
+		t.Errorf("heatmap = %+v", heatmap)
+	}
+
+	// tygodnie zaczynają się w poniedziałek (1 marca 2025 to sobota)
+	weeks, _ := bucketActivity(answers, from, to, "week", warsaw)
+	if len(weeks) != 2 || weeks[0].Start != "2025-02-24" || weeks[1].Start != "2025-03-03" {
+		t.Fatalf("weeks = %+v", weeks)
+	}
+	if weeks[0].Answers != 2 {
+		t.Errorf("week of 2025-02-24 answers = %d, want 2", weeks[0].Answers)
+	}
+}
+
+func TestComputeStreaks(t *testing.T) {
+	today := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name             string
+		days             []string
+		current, longest int
+	}{
+		{"none", nil, 0, 0},
+		{"active today", []string{"2025-03-08", "2025-03-09", "2025-03-10", "2025-03-10"}, 3, 3},
+		{"active yesterday", []string{"2025-03-09", "2025-03-08"}, 2, 2},
+		{"broken", []string{"2025-03-01", "2025-03-02", "2025-03-03", "2025-03-04", "2025-03-08"}, 0, 4},
+		{"month boundary", []string{"2025-02-28", "2025-03-01"}, 0, 2},
+	}
+	for _, tt := range tests {
+		s := computeStreaks(tt.days, today)
+		if s.Current != tt.current || s.Longest != tt.longest {
+			t.Errorf("%s: current=%d longest=%d, want %d/%d", tt.name, s.Current, s.Longest, tt.current, tt.longest)
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Egzamin adaptacyjny: trudność pytań z modelu Rascha (1PL), kalibrowana okresowo
+// z historii odpowiedzi; kolejne pytanie dobierane do bieżącej oceny umiejętności.
+
+const (
+	ExamTypeAdaptive = "adaptive"
+
+	calibrationMinResponses = 20 // mniej odpowiedzi na pytanie = brak kalibracji
+	calibrationMaxIter      = 100
+	calibrationTolerance    = 1e-4
+	abilityPriorSD          = 1.0 // prior N(0,1) na umiejętność (ekstremalne wyniki nie uciekają do ±∞)
+	difficultyPriorSD       = 2.0 // słaby prior na trudność pytania
+	logitClamp              = 6.0
+	adaptiveDefaultTargetSE = 0.3 // koniec, gdy błąd standardowy oceny spadnie do tej wartości
+	adaptiveMinTargetSE     = 0.2
+	adaptiveCandidates      = 3 // losujemy spośród 3 najlepiej dopasowanych (mniejsza ekspozycja pytań)
+)
+
+// raschP to prawdopodobieństwo poprawnej odpowiedzi przy umiejętności theta i trudności b.
+func raschP(theta, b float64) float64 {
+	return 1 / (1 + math.Exp(b-theta))
+}
+
+func clampLogit(v float64) float64 {
+	return math.Max(-logitClamp, math.Min(logitClamp, v))
+}
+
+// difficultyLevel mapuje trudność w logitach na skalę 1..5 (Question.Difficulty).
+func difficultyLevel(b float64) int {
+	switch {
+	case b < -1.5:
+		return 1
+	case b < -0.5:
+		return 2
+	case b < 0.5:
+		return 3
+	case b < 1.5:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// raschResponse to jedna odpowiedź: osoba (egzamin), pytanie, wynik.
+type raschResponse struct {
+	Person  string
+	Item    string
+	Correct bool
+}
+
+type itemCalibration struct {
+	B         float64
+	Responses int
+}
+
+// calibrateRasch dopasowuje model Rascha metodą JML z priorami (MAP) i zwraca trudności
+// pytań wycentrowane na średnią 0. Osobą jest pojedynczy egzamin (umiejętność w danym czasie).
+func calibrateRasch(responses []raschResponse) map[string]itemCalibration {
+	type obs struct {
+		person, item int
+		x            float64
+	}
+	persons, items := map[string]int{}, map[string]int{}
+	var itemIDs []string
+	var data []obs
+	for _, r := range responses {
+		p, ok := persons[r.Person]
+		if !ok {
+			p = len(persons)
+			persons[r.Person] = p
+		}
+		i, ok := items[r.Item]
+		if !ok {
+			i = len(items)
+			items[r.Item] = i
+			itemIDs = append(itemIDs, r.Item)
+		}
+		x := 0.0
+		if r.Correct {
+			x = 1
+		}
+		data = append(data, obs{p, i, x})
+	}
+	if len(data) == 0 {
+		return map[string]itemCalibration{}
+	}
+
+	theta := make([]float64, len(persons))
+	b := make([]float64, len(items))
+	counts := make([]int, len(items))
+	for _, o := range data {
+		counts[o.item]++
+	}
+	for iter := 0; iter < calibrationMaxIter; iter++ {
+		maxDelta := 0.0
+		// krok Newtona dla osób
+		g := make([]float64, len(theta))
+		h := make([]float64, len(theta))
+		for _, o := range data {
+			p := raschP(theta[o.person], b[o.item])
+			g[o.person] += o.x - p
+			h[o.person] += p * (1 - p)
+		}
+		for p := range theta {
+			step := (g[p] - theta[p]/(abilityPriorSD*abilityPriorSD)) / (h[p] + 1/(abilityPriorSD*abilityPriorSD))
+			theta[p] = clampLogit(theta[p] + step)
+			maxDelta = math.Max(maxDelta, math.Abs(step))
+		}
+		// krok Newtona dla pytań
+		g = make([]float64, len(b))
+		h = make([]float64, len(b))
+		for _, o := range data {
+			p := raschP(theta[o.person], b[o.item])
+			g[o.item] += p - o.x
+			h[o.item] += p * (1 - p)
+		}
+		for i := range b {
+			step := (g[i] - b[i]/(difficultyPriorSD*difficultyPriorSD)) / (h[i] + 1/(difficultyPriorSD*difficultyPriorSD))
+			b[i] = clampLogit(b[i] + step)
+			maxDelta = math.Max(maxDelta, math.Abs(step))
+		}
+		if maxDelta < calibrationTolerance {
+			break
+		}
+	}
+
+	mean := 0.0
+	for _, v := range b {
+		mean += v
+	}
+	mean /= float64(len(b))
+	out := make(map[string]itemCalibration, len(b))
+	for i, id := range itemIDs {
+		out[id] = itemCalibration{B: b[i] - mean, Responses: counts[i]}
+	}
+	return out
+}
+
+// CalibrateDifficulty przelicza trudność pytań każdego banku z pierwszych odpowiedzi
+// w egzaminach. Pytania z mniej niż calibrationMinResponses odpowiedziami zostają bez zmian.
+func CalibrateDifficulty(db *gorm.DB) (int, error) {
+	var banks []string
+	if err := db.Model(&QuestionBank{}).Pluck("id", &banks).Error; err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, bank := range banks {
+		var rows []struct {
+			ExamID     string
+			QuestionID string
+			IsCorrect  bool
+		}
+		if err := db.Table("answers a").
+			Select("a.exam_id as exam_id, a.question_id as question_id, a.is_correct as is_correct").
+			Joins("JOIN exams e ON e.id = a.exam_id").
+			Where("e.bank_id = ?", bank).
+			Order("a.id").
+			Scan(&rows).Error; err != nil {
+			return updated, err
+		}
+		seen := map[[2]string]bool{}
+		responses := make([]raschResponse, 0, len(rows))
+		for _, r := range rows {
+			key := [2]string{r.ExamID, r.QuestionID}
+			if seen[key] {
+				continue // liczy się pierwsza odpowiedź (jak w review)
+			}
+			seen[key] = true
+			responses = append(responses, raschResponse{Person: r.ExamID, Item: r.QuestionID, Correct: r.IsCorrect})
+		}
+
+		calib := calibrateRasch(responses)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for qid, c := range calib {
+				if c.Responses < calibrationMinResponses {
+					continue
+				}
+				b, level := c.B, difficultyLevel(c.B)
+				if err := tx.Model(&Question{}).Where("id = ?", qid).Updates(map[string]interface{}{
+					"difficulty_logit": b, "difficulty": level, "calibration_n": c.Responses,
+				}).Error; err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		})
+		if err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// StartDifficultyCalibration uruchamia w tle okresową kalibrację. interval <= 0 wyłącza job.
+func StartDifficultyCalibration(db *gorm.DB, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if n, err := CalibrateDifficulty(db); err != nil {
+				log.Printf("calibration: %v", err)
+			} else if n > 0 {
+				log.Printf("calibration: updated %d questions", n)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// POST /api/v1/admin/calibrate — kalibracja na żądanie
+func RunCalibration(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n, err := CalibrateDifficulty(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"calibrated": n})
+	}
+}
+
+// ===== Ocena umiejętności i dobór pytań =====
+
+type abilityResponse struct {
+	B       float64
+	Correct bool
+}
+
+type AbilityEstimate struct {
+	Theta float64  `json:"theta"` // logity; 0 = przeciętne pytanie banku ma 50% szans
+	SE    float64  `json:"se"`
+	CI95  Interval `json:"ci95"`
+	// ExpectedScorePercent to oczekiwany % poprawnych na skalibrowanych pytaniach banku.
+	ExpectedScorePercent *float64 `json:"expectedScorePercent,omitempty"`
+}
+
+// estimateAbility liczy ocenę MAP z priorem N(0,1); SE z informacji Fishera + prioru.
+func estimateAbility(responses []abilityResponse) (float64, float64) {
+	theta := 0.0
+	prior := 1 / (abilityPriorSD * abilityPriorSD)
+	for iter := 0; iter < 50; iter++ {
+		g, h := -theta*prior, prior
+		for _, r := range responses {
+			p := raschP(theta, r.B)
+			if r.Correct {
+				g += 1 - p
+			} else {
+				g -= p
+			}
+			h += p * (1 - p)
+		}
+		step := g / h
+		theta = clampLogit(theta + step)
+		if math.Abs(step) < 1e-6 {
+			break
+		}
+	}
+	info := prior
+	for _, r := range responses {
+		p := raschP(theta, r.B)
+		info += p * (1 - p)
+	}
+	return theta, 1 / math.Sqrt(info)
+}
+
+// adaptiveCandidate to pytanie z puli banku; B = 0 dla pytań bez kalibracji.
+type adaptiveCandidate struct {
+	ID string
+	B  float64
+}
+
+// pickAdaptiveQuestion wybiera pytanie o trudności najbliższej theta (maks. informacja
+// w modelu Rascha), losując spośród adaptiveCandidates najlepszych.
+func pickAdaptiveQuestion(pool []adaptiveCandidate, used map[string]bool, theta float64, rng *rand.Rand) (string, bool) {
+	var free []adaptiveCandidate
+	for _, q := range pool {
+		if !used[q.ID] {
+			free = append(free, q)
+		}
+	}
+	if len(free) == 0 {
+		return "", false
+	}
+	// tasowanie przed sortowaniem: równe B (np. wszystkie 0 przed kalibracją) w losowej kolejności, nie po ID
+	rng.Shuffle(len(free), func(i, j int) { free[i], free[j] = free[j], free[i] })
+	sort.SliceStable(free, func(i, j int) bool {
+		return math.Abs(free[i].B-theta) < math.Abs(free[j].B-theta)
+	})
+	k := minInt(adaptiveCandidates, len(free))
+	return free[rng.Intn(k)].ID, true
+}
+
+func loadAdaptivePool(db *gorm.DB, bankID string) ([]adaptiveCandidate, error) {
+	var qs []Question
+	if err := db.Select("id", "difficulty_logit").Where("bank_id = ?", bankID).Order("id").Find(&qs).Error; err != nil {
+		return nil, err
+	}
+	pool := make([]adaptiveCandidate, 0, len(qs))
+	for _, q := range qs {
+		c := adaptiveCandidate{ID: q.ID}
+		if q.DifficultyLogit != nil {
+			c.B = *q.DifficultyLogit
+		}
+		pool = append(pool, c)
+	}
+	return pool, nil
+}
+
+func adaptiveRNG(exam *Exam, served int) *rand.Rand {
+	if exam.Seed != nil {
+		return rand.New(rand.NewSource(*exam.Seed + int64(served)))
+	}
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// abilityOf liczy ocenę umiejętności z pierwszych odpowiedzi egzaminu.
+func abilityOf(db *gorm.DB, exam *Exam) (*AbilityEstimate, int, error) {
+	var answers []Answer
+	if err := db.Where("exam_id = ?", exam.ID).Order("id").Find(&answers).Error; err != nil {
+		return nil, 0, err
+	}
+	var qs []Question
+	if err := db.Select("id", "difficulty_logit").Where("id IN (?)",
+		db.Model(&ExamQuestion{}).Select("question_id").Where("exam_id = ?", exam.ID)).Find(&qs).Error; err != nil {
+		return nil, 0, err
+	}
+	bOf := map[string]float64{}
+	for _, q := range qs {
+		if q.DifficultyLogit != nil {
+			bOf[q.ID] = *q.DifficultyLogit
+		}
+	}
+	seen := map[string]bool{}
+	var rs []abilityResponse
+	for _, a := range answers {
+		if seen[a.QuestionID] {
+			continue
+		}
+		seen[a.QuestionID] = true
+		rs = append(rs, abilityResponse{B: bOf[a.QuestionID], Correct: a.IsCorrect})
+	}
+	theta, se := estimateAbility(rs)
+	est, err := newAbilityEstimate(db, exam.BankID, theta, se)
+	return est, len(rs), err
+}
+
+// newAbilityEstimate dokłada przedział i oczekiwany wynik na skalibrowanych pytaniach banku.
+func newAbilityEstimate(db *gorm.DB, bankID string, theta, se float64) (*AbilityEstimate, error) {
+	est := &AbilityEstimate{Theta: theta, SE: se, CI95: Interval{Low: theta - 1.96*se, High: theta + 1.96*se}}
+	var calibrated []float64
+	if err := db.Model(&Question{}).Where("bank_id = ? AND difficulty_logit IS NOT NULL", bankID).
+		Pluck("difficulty_logit", &calibrated).Error; err != nil {
+		return nil, err
+	}
+	if len(calibrated) > 0 {
+		sum := 0.0
+		for _, b := range calibrated {
+			sum += raschP(theta, b)
+		}
+		pct := sum * 100 / float64(len(calibrated))
+		est.ExpectedScorePercent = &pct
+	}
+	return est, nil
+}
+
+// pendingAdaptiveQuestion zwraca ostatnie wydane pytanie, jeśli nie ma jeszcze odpowiedzi.
+func pendingAdaptiveQuestion(db *gorm.DB, examID string) (string, error) {
+	var eq ExamQuestion
+	if err := db.Where("exam_id = ?", examID).Order("position DESC").First(&eq).Error; err != nil {
+		return "", err
+	}
+	var n int64
+	if err := db.Model(&Answer{}).Where("exam_id = ? AND question_id = ?", examID, eq.QuestionID).Count(&n).Error; err != nil {
+		return "", err
+	}
+	if n > 0 {
+		return "", nil
+	}
+	return eq.QuestionID, nil
+}
+
+// advanceAdaptive aktualizuje ocenę po odpowiedzi i wydaje kolejne pytanie,
+// chyba że osiągnięto docelowy SE albo limit pytań. Zwraca nil, gdy test się skończył.
+func advanceAdaptive(db *gorm.DB, exam *Exam) (*QuestionDTO, error) {
+	est, answered, err := abilityOf(db, exam)
+	if err != nil {
+		return nil, err
+	}
+	exam.AbilityTheta, exam.AbilitySE = &est.Theta, &est.SE
+	if err := db.Model(exam).Updates(map[string]interface{}{"ability_theta": est.Theta, "ability_se": est.SE}).Error; err != nil {
+		return nil, err
+	}
+	if est.SE <= exam.TargetSE || answered >= exam.MaxQuestions {
+		return nil, nil
+	}
+
+	pool, err := loadAdaptivePool(db, exam.BankID)
+	if err != nil {
+		return nil, err
+	}
+	var served []ExamQuestion
+	if err := db.Where("exam_id = ?", exam.ID).Find(&served).Error; err != nil {
+		return nil, err
+	}
+	used := map[string]bool{}
+	for _, eq := range served {
+		used[eq.QuestionID] = true
+	}
+	qid, ok := pickAdaptiveQuestion(pool, used, est.Theta, adaptiveRNG(exam, len(served)))
+	if !ok {
+		return nil, nil // pula wyczerpana
+	}
+	if err := db.Create(&ExamQuestion{ExamID: exam.ID, QuestionID: qid, Position: len(served) + 1}).Error; err != nil {
+		return nil, err
+	}
+	dtos, err := loadQuestionDTOs(db, []string{qid})
+	if err != nil {
+		return nil, err
+	}
+	return &dtos[0], nil
+}
+
+// startAdaptiveExam tworzy egzamin adaptacyjny z jednym (pierwszym) pytaniem;
+// kolejne przychodzą w odpowiedzi na POST /exams/:id/answer.
+func startAdaptiveExam(c *gin.Context, db *gorm.DB, req StartExamReq, bank *QuestionBank, userID *uint) {
+	if req.TargetSE == 0 {
+		req.TargetSE = adaptiveDefaultTargetSE
+	}
+	if req.TargetSE < adaptiveMinTargetSE || req.TargetSE > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "targetSE must be between 0.2 and 1"})
+		return
+	}
+	pool, err := loadAdaptivePool(db, bank.ID)
+	if err != nil || len(pool) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no questions"})
+		return
+	}
+
+	exam := Exam{
+		ID:              uuid.New().String(),
+		Type:            ExamTypeAdaptive,
+		StartedAt:       time.Now(),
+		DurationSeconds: req.DurationSec,
+		Seed:            req.Seed,
+		UserID:          userID,
+		BankID:          bank.ID,
+		PassPercent:     bank.PassPercent,
+		Scoring:         ScoringAllOrNothing, // ocena z modelu, punkty tylko w review
+		TargetSE:        req.TargetSE,
+		MaxQuestions:    minInt(req.Count, len(pool)),
+		MaxPauseSeconds: *req.MaxPauseSec,
+	}
+	first, _ := pickAdaptiveQuestion(pool, nil, 0, adaptiveRNG(&exam, 0))
+	if err := createExam(db, &exam, []string{first}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	out, err := loadQuestionDTOs(db, []string{first})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"examId":       exam.ID,
+		"type":         exam.Type,
+		"bank":         bank.ID,
+		"durationSec":  exam.DurationSeconds,
+		"maxQuestions": exam.MaxQuestions,
+		"targetSE":     exam.TargetSE,
+		"questions":    out,
+		"clock":        computeClock(exam, nil, time.Now()),
+	})
+}
+
+// abilityIfFinished zwraca zapisaną ocenę ukończonego egzaminu adaptacyjnego (lista egzaminów).
+func abilityIfFinished(e Exam) *float64 {
+	if e.Type != ExamTypeAdaptive || e.FinishedAt == nil {
+		return nil
+	}
+	return e.AbilityTheta
+}
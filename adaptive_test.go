@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// simulateRasch losuje odpowiedzi n osób o umiejętności ~N(0,1) na pytania o trudnościach bs.
+func simulateRasch(n int, bs map[string]float64, seed int64) []raschResponse {
+	rng := rand.New(rand.NewSource(seed))
+	var out []raschResponse
+	for p := 0; p < n; p++ {
+		theta := rng.NormFloat64()
+		for id, b := range bs {
+			out = append(out, raschResponse{Person: fmt.Sprint(p), Item: id, Correct: rng.Float64() < raschP(theta, b)})
+		}
+	}
+	return out
+}
+
+func TestCalibrateRaschRecoversDifficulty(t *testing.T) {
+	bs := map[string]float64{"easy": -1.5, "mid": 0, "hard": 1.5}
+	calib := calibrateRasch(simulateRasch(2000, bs, 1))
+	for id, want := range bs {
+		if got := calib[id]; math.Abs(got.B-want) > 0.35 || got.Responses != 2000 {
+			t.Errorf("%s: b = %.2f (n=%d), want ≈ %.1f", id, got.B, got.Responses, want)
+		}
+	}
+}
+
+func TestEstimateAbility(t *testing.T) {
+	theta0, se0 := estimateAbility(nil)
+	if theta0 != 0 || se0 != 1 {
+		t.Errorf("no responses: theta=%v se=%v, want prior 0/1", theta0, se0)
+	}
+	strong := []abilityResponse{{B: 1, Correct: true}, {B: 1.5, Correct: true}, {B: 0.5, Correct: true}, {B: 2, Correct: false}}
+	weak := []abilityResponse{{B: -1, Correct: false}, {B: -0.5, Correct: false}, {B: 0, Correct: true}, {B: -1.5, Correct: false}}
+	ts, ses := estimateAbility(strong)
+	tw, _ := estimateAbility(weak)
+	if ts <= 0 || tw >= 0 {
+		t.Errorf("strong theta = %.2f, weak theta = %.2f", ts, tw)
+	}
+	if ses >= se0 {
+		t.Errorf("SE did not shrink with responses: %.2f", ses)
+	}
+}
+
+func TestPickAdaptiveQuestion(t *testing.T) {
+	pool := []adaptiveCandidate{{"q1", -2}, {"q2", -0.1}, {"q3", 0.2}, {"q4", 0.4}, {"q5", 3}}
+	used := map[string]bool{"q3": true}
+	rng := rand.New(rand.NewSource(7))
+	// najbliżej 0.3: q4, q2, potem q1 (2.3) przed q5 (2.7); q3 już użyte
+	picked := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		id, ok := pickAdaptiveQuestion(pool, used, 0.3, rng)
+		if !ok || id == "q3" || id == "q5" {
+			t.Fatalf("picked %q", id)
+		}
+		picked[id] = true
+	}
+	if len(picked) != adaptiveCandidates {
+		t.Errorf("picked %v, want spread over %d candidates", picked, adaptiveCandidates)
+	}
+	if _, ok := pickAdaptiveQuestion(pool[:1], map[string]bool{"q1": true}, 0, rng); ok {
+		t.Error("exhausted pool should return false")
+	}
+}
+
+func TestPickAdaptiveQuestionUncalibratedPool(t *testing.T) {
+	// przed kalibracją wszystkie B = 0: kolejność nie może iść po ID
+	var pool []adaptiveCandidate
+	for i := 1; i <= 20; i++ {
+		pool = append(pool, adaptiveCandidate{ID: fmt.Sprintf("b:%03d", i)})
+	}
+	order := func(seed int64) []string {
+		used := map[string]bool{}
+		var ids []string
+		for i := 0; i < 5; i++ {
+			id, _ := pickAdaptiveQuestion(pool, used, 0, rand.New(rand.NewSource(seed+int64(i))))
+			used[id] = true
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	orders := map[string]bool{}
+	firstOutsideTop3 := false
+	for seed := int64(1); seed <= 10; seed++ {
+		ids := order(seed)
+		orders[strings.Join(ids, ",")] = true
+		if ids[0] > "b:003" {
+			firstOutsideTop3 = true
+		}
+	}
+	if len(orders) < 2 {
+		t.Errorf("different seeds gave the same order: %v", orders)
+	}
+	if !firstOutsideTop3 {
+		t.Error("first question always among the first 3 IDs")
+	}
+}
+
+func TestCalibrateDifficultyUpdatesQuestions(t *testing.T) {
+	db := newTestDB(t)
+	db.Create(&QuestionBank{ID: "b", Name: "B", DefaultQuestionCount: 3, DefaultDurationSec: 60, PassPercent: 61})
+	bs := map[string]float64{"b:1": -1, "b:2": 1}
+	for id := range bs {
+		db.Create(&Question{ID: id, BankID: "b", TextEN: id})
+	}
+	db.Create(&Question{ID: "b:3", BankID: "b", TextEN: "rarely answered"})
+	for i, r := range simulateRasch(100, bs, 2) {
+		examID := "e" + r.Person
+		if i%2 == 0 {
+			db.Create(&Exam{ID: examID, Type: "exam", BankID: "b", DurationSeconds: 60})
+		}
+		db.Create(&Answer{ExamID: examID, QuestionID: r.Item, SelectedRaw: "[]", IsCorrect: r.Correct})
+	}
+	db.Create(&Answer{ExamID: "e0", QuestionID: "b:3", SelectedRaw: "[]"})
+
+	n, err := CalibrateDifficulty(db)
+	if err != nil || n != 2 {
+		t.Fatalf("calibrated %d (err %v), want 2", n, err)
+	}
+	var easy, hard, rare Question
+	db.First(&easy, "id = ?", "b:1")
+	db.First(&hard, "id = ?", "b:2")
+	db.First(&rare, "id = ?", "b:3")
+	if easy.DifficultyLogit == nil || hard.DifficultyLogit == nil || *easy.DifficultyLogit >= *hard.DifficultyLogit {
+		t.Fatalf("logits easy=%v hard=%v", easy.DifficultyLogit, hard.DifficultyLogit)
+	}
+	if *easy.Difficulty >= *hard.Difficulty || easy.CalibrationN != 100 {
+		t.Errorf("difficulty levels easy=%d hard=%d n=%d", *easy.Difficulty, *hard.Difficulty, easy.CalibrationN)
+	}
+	if rare.DifficultyLogit != nil {
+		t.Error("question below the response minimum should stay uncalibrated")
+	}
+}
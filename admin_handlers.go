@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /api/v1/admin/item-analysis
+// Query params: ?sort=pValue|pointBiserial|discrimination|answered|id&order=asc|desc
+// plus ?bank=&minAnswers=0&flag=negative_discrimination&format=json|csv
+func ItemAnalysis(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bankID := ""
+		if c.Query("bank") != "" { // bez ?bank= wszystkie banki
+			bank, ok := bankFromRequest(c, db, c.Query("bank"))
+			if !ok {
+				return
+			}
+			bankID = bank.ID
+		}
+		responses, err := loadItemResponses(db, bankID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		var opts []Option
+		if err := db.Find(&opts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		options := map[string][]Option{}
+		for _, o := range opts {
+			options[o.QuestionID] = append(options[o.QuestionID], o)
+		}
+		items := computeItemStats(responses, options)
+
+		// filters
+		minAnswers, _ := strconv.Atoi(c.Query("minAnswers"))
+		flag := c.Query("flag")
+		filtered := items[:0]
+		for _, it := range items {
+			if it.Answered < minAnswers {
+				continue
+			}
+			if flag != "" && !containsString(it.Flags, flag) {
+				continue
+			}
+			filtered = append(filtered, it)
+		}
+		items = filtered
+
+		// sort (nil values always last)
+		desc := c.Query("order") == "desc"
+		key := c.DefaultQuery("sort", "id")
+		less, ok := itemSortKeys[key]
+		if !ok && key != "id" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be id|answered|pValue|pointBiserial|discrimination"})
+			return
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			if key == "id" {
+				if desc {
+					return questionIDLess(items[j].QuestionID, items[i].QuestionID)
+				}
+				return questionIDLess(items[i].QuestionID, items[j].QuestionID)
+			}
+			a, b := less(items[i]), less(items[j])
+			if a == nil || b == nil {
+				return a != nil
+			}
+			if desc {
+				return *a > *b
+			}
+			return *a < *b
+		})
+
+		if c.Query("format") == "csv" {
+			writeItemAnalysisCSV(c, items)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"total": len(items), "items": items})
+	}
+}
+
+// questionIDLess porównuje ID pytań jako (bank, numer): "cx:2" < "cx:10" < "dev:1".
+// Nienumeryczne ID w banku porównujemy jako tekst, po numerycznych.
+func questionIDLess(a, b string) bool {
+	bankA, bankB := strings.TrimSuffix(a, localQuestionID(a)), strings.TrimSuffix(b, localQuestionID(b))
+	if bankA != bankB {
+		return bankA < bankB
+	}
+	la, lb := localQuestionID(a), localQuestionID(b)
+	na, errA := strconv.Atoi(la)
+	nb, errB := strconv.Atoi(lb)
+	switch {
+	case errA == nil && errB == nil && na != nb:
+		return na < nb
+	case (errA == nil) != (errB == nil):
+		return errA == nil
+	}
+	return la < lb
+}
+
+// itemSortKeys mapuje ?sort na wartość liczbową (nil = brak danych); "id" sortuje questionIDLess.
+var itemSortKeys = map[string]func(ItemStats) *float64{
+	"answered":       func(it ItemStats) *float64 { v := float64(it.Answered); return &v },
+	"pValue":         func(it ItemStats) *float64 { return &it.PValue },
+	"pointBiserial":  func(it ItemStats) *float64 { return it.PointBiserial },
+	"discrimination": func(it ItemStats) *float64 { return it.Discrimination },
+}
+
+func writeItemAnalysisCSV(c *gin.Context, items []ItemStats) {
+	fmtOpt := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f, 'f', 3, 64)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="item-analysis-%s.csv"`, time.Now().Format("2006-01-02")))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"question_id", "answered", "p_value", "point_biserial", "upper_p", "lower_p", "discrimination", "option_frequencies", "unused_distractors", "flags"})
+	for _, it := range items {
+		freqs := make([]string, 0, len(it.Options))
+		for _, o := range it.Options {
+			mark := ""
+			if o.IsCorrect {
+				mark = "*"
+			}
+			freqs = append(freqs, fmt.Sprintf("%s%s=%.1f", o.Key, mark, o.Frequency))
+		}
+		_ = w.Write([]string{
+			it.QuestionID,
+			strconv.Itoa(it.Answered),
+			strconv.FormatFloat(it.PValue, 'f', 1, 64),
+			fmtOpt(it.PointBiserial),
+			fmtOpt(it.UpperPValue),
+			fmtOpt(it.LowerPValue),
+			fmtOpt(it.Discrimination),
+			strings.Join(freqs, " "),
+			strings.Join(it.UnusedDistractors, " "),
+			strings.Join(it.Flags, " "),
+		})
+	}
+	w.Flush()
+}
+
+func containsString(xs []string, s string) bool {
+	for _, x := range xs {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+type SetUserRoleReq struct {
+	Role string `json:"role"` // "" | "author" | "reviewer" | "admin"
+}
+
+// PUT /api/v1/admin/users/:publicId/role
+// Nadaje rolę (np. author dla zaufanych kursantów); "" odbiera rolę.
+func SetUserRole(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SetUserRoleReq
+		if err := c.BindJSON(&req); err != nil || (req.Role != "" && !containsString([]string{RoleAuthor, RoleReviewer, RoleAdmin}, req.Role)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "role must be author|reviewer|admin or empty"})
+			return
+		}
+		var u User
+		if err := db.Where("public_id = ?", c.Param("publicId")).First(&u).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if err := db.Model(&u).Update("role", req.Role).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": u.PublicID, "role": req.Role})
+	}
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CreateAssignmentReq struct {
+	Title       string     `json:"title"`
+	Bank        string     `json:"bank"`        // default: bank domyślny
+	Count       int        `json:"count"`       // default z banku (80); ignorowane przy questionIds
+	DurationSec int        `json:"durationSec"` // default z banku (10800)
+	Seed        *int64     `json:"seed"`        // opcjonalnie; bez niego losujemy i zapisujemy seed
+	QuestionIDs []string   `json:"questionIds"` // opcjonalnie: jawna lista pytań
+	OpensAt     *time.Time `json:"opensAt"`     // default: teraz
+	ClosesAt    time.Time  `json:"closesAt"`
+}
+
+type AssignmentDTO struct {
+	Assignment
+	QuestionCount int    `json:"questionCount"`
+	IsOpen        bool   `json:"isOpen"`
+	MyExamID      string `json:"myExamId,omitempty"`
+	MyStatus      string `json:"myStatus"` // not_started | in_progress | finished
+}
+
+type AssignmentMemberResult struct {
+	UserID       uint       `json:"userId"`
+	DisplayName  *string    `json:"displayName,omitempty"`
+	Status       string     `json:"status"`
+	ExamID       string     `json:"examId,omitempty"`
+	FinishedAt   *time.Time `json:"finishedAt,omitempty"`
+	ScorePercent *float64   `json:"scorePercent,omitempty"`
+	Passed       *bool      `json:"passed,omitempty"`
+}
+
+type AssignmentQuestionStat struct {
+	Position       int      `json:"position"`
+	QuestionID     string   `json:"questionId"`
+	Answered       int64    `json:"answered"`
+	Correct        int64    `json:"correct"`
+	PercentCorrect *float64 `json:"percentCorrect,omitempty"` // niski = trudne dla grupy
+}
+
+func (a *Assignment) isOpen(now time.Time) bool {
+	return !now.Before(a.OpensAt) && now.Before(a.ClosesAt)
+}
+
+func assignmentStatus(e *Exam) string {
+	switch {
+	case e == nil:
+		return "not_started"
+	case e.FinishedAt != nil:
+		return "finished"
+	default:
+		return "in_progress"
+	}
+}
+
+func assignmentQuestionIDs(db *gorm.DB, assignmentID uint) ([]string, error) {
+	var ids []string
+	err := db.Model(&AssignmentQuestion{}).
+		Where("assignment_id = ?", assignmentID).
+		Order("position").
+		Pluck("question_id", &ids).Error
+	return ids, err
+}
+
+// POST /api/v1/groups/:id/assignments (trener)
+func CreateAssignment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		g, self, ok := groupAccess(c, db, true)
+		if !ok {
+			return
+		}
+		var req CreateAssignmentReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		title := strings.TrimSpace(req.Title)
+		if title == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "title required"})
+			return
+		}
+		opensAt := time.Now()
+		if req.OpensAt != nil {
+			opensAt = *req.OpensAt
+		}
+		if !req.ClosesAt.After(opensAt) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "closesAt must be after opensAt"})
+			return
+		}
+		bank, ok := bankFromRequest(c, db, req.Bank)
+		if !ok {
+			return
+		}
+		if req.DurationSec <= 0 {
+			req.DurationSec = bank.DefaultDurationSec
+		}
+
+		var qids []string
+		if len(req.QuestionIDs) > 0 {
+			seen := map[string]bool{}
+			for _, id := range req.QuestionIDs {
+				id = strings.TrimSpace(id)
+				if id != "" && !seen[id] {
+					seen[id] = true
+					qids = append(qids, id)
+				}
+			}
+			var found int64
+			if err := db.Model(&Question{}).Where("id IN ? AND bank_id = ?", qids, bank.ID).Count(&found).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			if int(found) != len(qids) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown question ids in bank " + bank.ID})
+				return
+			}
+		} else {
+			if req.Count <= 0 {
+				req.Count = bank.DefaultQuestionCount
+			}
+			if req.Seed == nil {
+				seed := time.Now().UnixNano()
+				req.Seed = &seed
+			}
+			var all []string
+			if err := db.Model(&Question{}).Where("bank_id = ?", bank.ID).Order("id").Pluck("id", &all).Error; err != nil || len(all) == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "no questions"})
+				return
+			}
+			qids = drawQuestions(all, req.Count, req.Seed)
+		}
+
+		a := Assignment{
+			GroupID:         g.ID,
+			CreatedBy:       self.UserID,
+			Title:           title,
+			OpensAt:         opensAt,
+			ClosesAt:        req.ClosesAt,
+			DurationSeconds: req.DurationSec,
+			Seed:            req.Seed,
+			BankID:          bank.ID,
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&a).Error; err != nil {
+				return err
+			}
+			for i, qid := range qids {
+				aq := AssignmentQuestion{AssignmentID: a.ID, QuestionID: qid, Position: i + 1}
+				if err := tx.Create(&aq).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusCreated, AssignmentDTO{
+			Assignment:    a,
+			QuestionCount: len(qids),
+			IsOpen:        a.isOpen(time.Now()),
+			MyStatus:      assignmentStatus(nil),
+		})
+	}
+}
+
+// GET /api/v1/groups/:id/assignments
+func ListAssignments(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		g, self, ok := groupAccess(c, db, false)
+		if !ok {
+			return
+		}
+		var as []Assignment
+		if err := db.Where("group_id = ?", g.ID).Order("closes_at DESC").Find(&as).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		now := time.Now()
+		out := make([]AssignmentDTO, 0, len(as))
+		for _, a := range as {
+			var count int64
+			_ = db.Model(&AssignmentQuestion{}).Where("assignment_id = ?", a.ID).Count(&count).Error
+			dto := AssignmentDTO{Assignment: a, QuestionCount: int(count), IsOpen: a.isOpen(now)}
+			var e Exam
+			if err := db.First(&e, "assignment_id = ? AND user_id = ?", a.ID, self.UserID).Error; err == nil {
+				dto.MyExamID = e.ID
+				dto.MyStatus = assignmentStatus(&e)
+			} else {
+				dto.MyStatus = assignmentStatus(nil)
+			}
+			out = append(out, dto)
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// startAssignedExam to ścieżka StartExam dla {"assignmentId": N}: tworzy egzamin
+// z zestawu pytań zadania, o ile user jest w grupie, a okno jest otwarte.
+// Jedno podejście na usera (unikalny indeks assignment_id + user_id); czas egzaminu
+// kończy się najpóźniej z zamknięciem okna.
+func startAssignedExam(c *gin.Context, db *gorm.DB, assignmentID uint) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+		return
+	}
+	var a Assignment
+	if err := db.First(&a, assignmentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "assignment not found"})
+		return
+	}
+	var m GroupMember
+	if err := db.First(&m, "group_id = ? AND user_id = ?", a.GroupID, uid).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a group member"})
+		return
+	}
+	now := time.Now()
+	duration := assignedDuration(&a, now)
+	if !a.isOpen(now) || duration <= 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "assignment is not open"})
+		return
+	}
+	if rejectIfAssignmentStarted(c, db, a.ID, uid) {
+		return
+	}
+
+	qids, err := assignmentQuestionIDs(db, a.ID)
+	if err != nil || len(qids) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	exam := Exam{
+		ID:              uuid.New().String(),
+		Type:            "exam",
+		StartedAt:       now,
+		DurationSeconds: duration,
+		Seed:            a.Seed,
+		UserID:          &uid,
+		AssignmentID:    &a.ID,
+		BankID:          a.BankID,
+	}
+	// próg zdawalności bieżący dla banku zadania
+	if b, err := findBank(db, a.BankID); err == nil {
+		exam.PassPercent = b.PassPercent
+	}
+	if err := createExam(db, &exam, qids); err != nil {
+		// równoległy start przegrał z unikalnym indeksem
+		if !rejectIfAssignmentStarted(c, db, a.ID, uid) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		}
+		return
+	}
+	out, err := loadQuestionDTOs(db, qids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"examId":       exam.ID,
+		"assignmentId": a.ID,
+		"durationSec":  exam.DurationSeconds,
+		"questions":    out,
+		"clock":        computeClock(exam, nil, time.Now()),
+	})
+}
+
+// assignedDuration to czas egzaminu z zadania obcięty do zamknięcia okna.
+func assignedDuration(a *Assignment, now time.Time) int {
+	left := int(a.ClosesAt.Sub(now) / time.Second)
+	if left < a.DurationSeconds {
+		return left
+	}
+	return a.DurationSeconds
+}
+
+// rejectIfAssignmentStarted odpowiada 409, gdy user ma już podejście do zadania; true = odpowiedź wysłana.
+func rejectIfAssignmentStarted(c *gin.Context, db *gorm.DB, assignmentID, uid uint) bool {
+	var existing Exam
+	if err := db.First(&existing, "assignment_id = ? AND user_id = ?", assignmentID, uid).Error; err != nil {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "assignment already started", "examId": existing.ID})
+	return true
+}
+
+// GET /api/v1/groups/:id/assignments/:aid/results (trener)
+func AssignmentResults(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		g, _, ok := groupAccess(c, db, true)
+		if !ok {
+			return
+		}
+		aid, err := strconv.ParseUint(c.Param("aid"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad assignment id"})
+			return
+		}
+		var a Assignment
+		if err := db.First(&a, "id = ? AND group_id = ?", aid, g.ID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "assignment not found"})
+			return
+		}
+
+		ms, users, err := loadGroupMembers(db, g.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		var exams []Exam
+		if err := db.Where("assignment_id = ?", a.ID).Find(&exams).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		byUser := map[uint]*Exam{}
+		examIDs := make([]string, 0, len(exams))
+		for i := range exams {
+			if exams[i].UserID != nil {
+				byUser[*exams[i].UserID] = &exams[i]
+			}
+			examIDs = append(examIDs, exams[i].ID)
+		}
+
+		members := make([]AssignmentMemberResult, 0, len(ms))
+		completed := 0
+		var scoreSum float64
+		for _, m := range ms {
+			e := byUser[m.UserID]
+			r := AssignmentMemberResult{UserID: m.UserID, DisplayName: users[m.UserID].DisplayName, Status: assignmentStatus(e)}
+			if e != nil {
+				r.ExamID = e.ID
+				r.FinishedAt = e.FinishedAt
+				r.ScorePercent = e.ScorePercent
+				r.Passed = examPassed(*e)
+				if e.ScorePercent != nil {
+					completed++
+					scoreSum += *e.ScorePercent
+				}
+			}
+			members = append(members, r)
+		}
+		var avg *float64
+		if completed > 0 {
+			v := scoreSum / float64(completed)
+			avg = &v
+		}
+
+		// trudność pytań w grupie: % poprawnych odpowiedzi w egzaminach tego zadania
+		type Row struct {
+			QuestionID string
+			Answered   int64
+			Correct    int64
+		}
+		var rows []Row
+		if len(examIDs) > 0 {
+			if err := db.Table("answers").
+				Select("question_id, COUNT(*) as answered, SUM(CASE WHEN is_correct THEN 1 ELSE 0 END) as correct").
+				Where("exam_id IN ?", examIDs).
+				Group("question_id").
+				Scan(&rows).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+		}
+		byQ := map[string]Row{}
+		for _, r := range rows {
+			byQ[r.QuestionID] = r
+		}
+		qids, err := assignmentQuestionIDs(db, a.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		questions := make([]AssignmentQuestionStat, 0, len(qids))
+		for i, qid := range qids {
+			r := byQ[qid]
+			ac := answerCount{Total: r.Answered, Correct: r.Correct}
+			questions = append(questions, AssignmentQuestionStat{
+				Position:       i + 1,
+				QuestionID:     qid,
+				Answered:       r.Answered,
+				Correct:        r.Correct,
+				PercentCorrect: ac.Accuracy(),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"assignment":   a,
+			"members":      members,
+			"completed":    completed,
+			"averageScore": avg,
+			"questions":    questions,
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// assignment tworzy zadanie z jednym pytaniem (q1) w grupie fixture'a.
+func (f *accessFixture) assignment(t *testing.T, opensAt, closesAt time.Time) Assignment {
+	t.Helper()
+	var g Group
+	f.db.First(&g, "owner_id = ?", f.trainer.ID)
+	a := Assignment{GroupID: g.ID, CreatedBy: f.trainer.ID, Title: "week 1", OpensAt: opensAt, ClosesAt: closesAt, DurationSeconds: 3600}
+	if err := f.db.Create(&a).Error; err != nil {
+		t.Fatal(err)
+	}
+	f.db.Create(&AssignmentQuestion{AssignmentID: a.ID, QuestionID: "q1", Position: 1})
+	return a
+}
+
+func (f *accessFixture) startAssignment(a Assignment, u *User) (int, map[string]any) {
+	w := f.do("POST", "/api/v1/exams", fmt.Sprintf(`{"assignmentId":%d}`, a.ID), u)
+	var body map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body
+}
+
+func TestStartAssignedExamWindow(t *testing.T) {
+	f := newAccessFixture(t)
+	now := time.Now()
+	future := f.assignment(t, now.Add(time.Hour), now.Add(2*time.Hour))
+	past := f.assignment(t, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	closing := f.assignment(t, now.Add(-time.Hour), now.Add(10*time.Minute))
+
+	for name, a := range map[string]Assignment{"not open yet": future, "closed": past} {
+		if code, body := f.startAssignment(a, f.owner); code != http.StatusForbidden {
+			t.Errorf("%s: status %d, want 403 (%v)", name, code, body)
+		}
+	}
+	if code, _ := f.startAssignment(closing, f.other); code != http.StatusForbidden {
+		t.Errorf("non-member: status %d, want 403", code)
+	}
+
+	code, body := f.startAssignment(closing, f.owner)
+	if code != http.StatusOK {
+		t.Fatalf("open assignment: status %d (%v)", code, body)
+	}
+	// 1h egzaminu, ale okno zamyka się za 10 minut
+	if d := body["durationSec"].(float64); d > 600 || d < 590 {
+		t.Errorf("durationSec = %v, want capped at closesAt", d)
+	}
+	if clock, ok := body["clock"].(map[string]any); !ok || clock["remainingSec"].(float64) > 600 || clock["pauseAllowanceSec"].(float64) != 0 {
+		t.Errorf("clock = %v, want remaining time and no pause allowance", body["clock"])
+	}
+}
+
+func TestAssignedExamSingleAttempt(t *testing.T) {
+	f := newAccessFixture(t)
+	a := f.assignment(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+
+	code, body := f.startAssignment(a, f.owner)
+	if code != http.StatusOK {
+		t.Fatalf("first start: status %d (%v)", code, body)
+	}
+	examID := body["examId"].(string)
+	if code, body := f.startAssignment(a, f.owner); code != http.StatusConflict || body["examId"] != examID {
+		t.Errorf("second start: status %d (%v), want 409 with the first exam", code, body)
+	}
+	for _, req := range []struct{ method, path string }{
+		{"POST", "/api/v1/exams/" + examID + "/abandon"},
+		{"DELETE", "/api/v1/exams/" + examID},
+	} {
+		if w := f.do(req.method, req.path, "", f.owner); w.Code != http.StatusConflict {
+			t.Errorf("%s %s: status %d, want 409 (%s)", req.method, req.path, w.Code, w.Body.String())
+		}
+	}
+
+	// równoległe starty innego członka: dokładnie jedno podejście
+	f.db.Create(&GroupMember{GroupID: a.GroupID, UserID: f.other.ID, Role: GroupRoleMember, JoinedAt: time.Now()})
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.startAssignment(a, f.other)
+		}()
+	}
+	wg.Wait()
+	var attempts int64
+	f.db.Model(&Exam{}).Where("assignment_id = ? AND user_id = ?", a.ID, f.other.ID).Count(&attempts)
+	if attempts != 1 {
+		t.Errorf("parallel starts created %d attempts, want 1", attempts)
+	}
+}
+
+func TestAssignmentUniqueIndex(t *testing.T) {
+	db := newTestDB(t)
+	uid, aid := uint(1), uint(1)
+	db.Create(&Exam{ID: "a1", Type: "exam", UserID: &uid, AssignmentID: &aid, StartedAt: time.Now(), DurationSeconds: 60})
+	if err := db.Create(&Exam{ID: "a2", Type: "exam", UserID: &uid, AssignmentID: &aid, StartedAt: time.Now(), DurationSeconds: 60}).Error; err == nil {
+		t.Error("second attempt at the same assignment was stored")
+	}
+	if err := db.Create(&Exam{ID: "p1", Type: "exam", UserID: &uid, StartedAt: time.Now(), DurationSeconds: 60}).Error; err != nil {
+		t.Errorf("practice exam rejected: %v", err)
+	}
+	if err := db.Create(&Exam{ID: "p2", Type: "exam", UserID: &uid, StartedAt: time.Now(), DurationSeconds: 60}).Error; err != nil {
+		t.Errorf("second practice exam rejected: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// questionIDSep oddziela bank od ID pytania z pliku: "commerce-dev:001".
+const questionIDSep = ":"
+
+var bankIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+func validBankID(id string) bool {
+	return bankIDPattern.MatchString(id)
+}
+
+func bankQuestionID(bankID, localID string) string {
+	return bankID + questionIDSep + localID
+}
+
+// localQuestionID zwraca ID pytania w obrębie banku ("commerce-dev:001" -> "001").
+func localQuestionID(id string) string {
+	if i := strings.Index(id, questionIDSep); i >= 0 {
+		return id[i+len(questionIDSep):]
+	}
+	return id
+}
+
+var errUnknownBank = errors.New("unknown bank")
+
+// findBank zwraca bank o podanym ID albo domyślny, gdy id jest puste.
+func findBank(db *gorm.DB, id string) (*QuestionBank, error) {
+	var b QuestionBank
+	tx := db
+	if id == "" {
+		tx = tx.Where("is_default = ?", true)
+	} else {
+		tx = tx.Where("id = ?", id)
+	}
+	if err := tx.First(&b).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errUnknownBank
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+// bankFromRequest rozwiązuje ?bank= (albo podane id); odpowiedź z błędem wysyła sam.
+func bankFromRequest(c *gin.Context, db *gorm.DB, id string) (*QuestionBank, bool) {
+	b, err := findBank(db, id)
+	switch {
+	case errors.Is(err, errUnknownBank):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown bank (GET /api/v1/banks)"})
+		return nil, false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return nil, false
+	}
+	return b, true
+}
+
+type BankDTO struct {
+	QuestionBank
+	QuestionCount int64 `json:"questionCount"`
+}
+
+// GET /api/v1/banks
+func ListBanks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var banks []QuestionBank
+		if err := db.Order("id").Find(&banks).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		type Row struct {
+			BankID string
+			C      int64
+		}
+		var rows []Row
+		if err := db.Model(&Question{}).Select("bank_id as bank_id, COUNT(*) as c").Group("bank_id").Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		counts := map[string]int64{}
+		for _, r := range rows {
+			counts[r.BankID] = r.C
+		}
+		out := make([]BankDTO, 0, len(banks))
+		for _, b := range banks {
+			out = append(out, BankDTO{QuestionBank: b, QuestionCount: counts[b.ID]})
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
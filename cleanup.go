@@ -0,0 +1,81 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// anonymousUsers zawęża zapytanie do userów bez displayName, e-maila i roli.
+func anonymousUsers(db *gorm.DB) *gorm.DB {
+	return db.Model(&User{}).Where("display_name IS NULL AND email IS NULL AND role = ''")
+}
+
+// userOwnedRows: tabela i kolumna z ID usera; wiersz w którejkolwiek chroni usera przed czyszczeniem.
+var userOwnedRows = []struct{ table, column string }{
+	{"exams", "user_id"}, // a więc i odpowiedzi
+	{"groups", "owner_id"},
+	{"group_members", "user_id"},
+	{"comments", "user_id"},
+	{"comment_votes", "user_id"},
+	{"question_reports", "user_id"},
+	{"question_proposals", "author_id"},
+	{"assignments", "created_by"},
+}
+
+// inactiveUsers zawęża zapytanie do anonimowych userów bez żadnych danych
+// (egzaminów, grup, zadań, komentarzy, zgłoszeń, propozycji) — kandydatów do czyszczenia.
+func inactiveUsers(db *gorm.DB) *gorm.DB {
+	tx := anonymousUsers(db)
+	for _, o := range userOwnedRows {
+		tx = tx.Where("NOT EXISTS (SELECT 1 FROM " + o.table + " t WHERE t." + o.column + " = users.id)")
+	}
+	return tx
+}
+
+// PurgeAnonymousUsers usuwa nieaktywnych anonimowych userów starszych niż maxAge,
+// razem z ich tokenami usunięcia i wpisami w rankingach.
+func PurgeAnonymousUsers(db *gorm.DB, maxAge time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-maxAge)
+	var n int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := inactiveUsers(tx).Where("created_at < ?", cutoff).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		for _, m := range []interface{}{&DeletionToken{}, &LeaderboardEntry{}} {
+			if err := tx.Where("user_id IN ?", ids).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Where("id IN ?", ids).Delete(&User{})
+		n = res.RowsAffected
+		return res.Error
+	})
+	return n, err
+}
+
+// StartUserCleanup uruchamia w tle okresowe czyszczenie anonimowych userów.
+// maxAge <= 0 wyłącza job.
+func StartUserCleanup(db *gorm.DB, maxAge, interval time.Duration) {
+	if maxAge <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			n, err := PurgeAnonymousUsers(db, maxAge)
+			if err != nil {
+				log.Printf("user cleanup: %v", err)
+			} else if n > 0 {
+				log.Printf("user cleanup: purged %d anonymous users", n)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPurgeAnonymousUsers(t *testing.T) {
+	db := newTestDB(t)
+
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	name := "Ala"
+	users := []User{
+		{PublicID: "anon-old", CreatedAt: old},
+		{PublicID: "anon-new"},
+		{PublicID: "named-old", DisplayName: &name, CreatedAt: old},
+		{PublicID: "anon-old-with-exam", CreatedAt: old},
+		{PublicID: "anon-old-trainer", CreatedAt: old},
+		{PublicID: "anon-old-member", CreatedAt: old},
+		{PublicID: "anon-old-commenter", CreatedAt: old},
+		{PublicID: "anon-old-voter", CreatedAt: old},
+		{PublicID: "anon-old-reporter", CreatedAt: old},
+		{PublicID: "anon-old-proposer", CreatedAt: old},
+		{PublicID: "anon-old-assigner", CreatedAt: old},
+	}
+	for i := range users {
+		if err := db.Create(&users[i]).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	uid := users[3].ID
+	if err := db.Create(&Exam{ID: "e1", UserID: &uid, Type: "exam", StartedAt: old, DurationSeconds: 60}).Error; err != nil {
+		t.Fatalf("create exam: %v", err)
+	}
+
+	g := Group{Name: "g", InviteCode: "code", OwnerID: users[4].ID}
+	db.Create(&g)
+	db.Create(&GroupMember{GroupID: g.ID, UserID: users[5].ID, Role: GroupRoleMember, JoinedAt: old})
+	c := Comment{QuestionID: "q1", UserID: users[6].ID, Body: "x"}
+	db.Create(&c)
+	db.Create(&CommentVote{CommentID: c.ID, UserID: users[7].ID})
+	db.Create(&QuestionReport{QuestionID: "q1", UserID: users[8].ID, Category: "typo", Status: "open"})
+	db.Create(&QuestionProposal{AuthorID: users[9].ID, BankID: "cx", Payload: "{}", Status: "draft"})
+	db.Create(&Assignment{GroupID: g.ID, CreatedBy: users[10].ID, Title: "a", OpensAt: old, ClosesAt: old, DurationSeconds: 60})
+	db.Create(&DeletionToken{UserID: users[0].ID, Token: "t", ExpiresAt: time.Now()})
+	db.Create(&LeaderboardEntry{Board: BoardWeeklyAnswers, UserID: users[0].ID, Value: 1, Volume: 1, ComputedAt: old})
+
+	n, err := PurgeAnonymousUsers(db, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("purged = %d, want 1", n)
+	}
+	var left []string
+	db.Model(&User{}).Order("public_id").Pluck("public_id", &left)
+	want := []string{"anon-new", "anon-old-assigner", "anon-old-commenter", "anon-old-member", "anon-old-proposer", "anon-old-reporter",
+		"anon-old-trainer", "anon-old-voter", "anon-old-with-exam", "named-old"}
+	if len(left) != len(want) {
+		t.Fatalf("left = %v, want %v", left, want)
+	}
+	for i := range want {
+		if left[i] != want[i] {
+			t.Errorf("left = %v, want %v", left, want)
+			break
+		}
+	}
+	for _, m := range []interface{}{&DeletionToken{}, &LeaderboardEntry{}} {
+		var n int64
+		db.Model(m).Where("user_id = ?", users[0].ID).Count(&n)
+		if n != 0 {
+			t.Errorf("%T rows left for purged user: %d", m, n)
+		}
+	}
+}
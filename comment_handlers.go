@@ -0,0 +1,419 @@
+package main
+
+import (
+	"errors"
+	"html"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	commentMaxLen     = 5000
+	commentRateWindow = time.Minute
+	commentRateMax    = 5   // komentarzy na minutę per user
+	commentDailyMax   = 100 // komentarzy na dobę per user
+)
+
+type CommentReq struct {
+	Body     string `json:"body"`
+	ParentID *uint  `json:"parentId"` // odpowiedź w wątku
+}
+
+type CommentDTO struct {
+	ID          uint         `json:"id"`
+	ParentID    *uint        `json:"parentId,omitempty"`
+	Author      string       `json:"author"`
+	Mine        bool         `json:"mine"`
+	Body        string       `json:"body"`
+	Upvotes     int          `json:"upvotes"`
+	UpvotedByMe bool         `json:"upvotedByMe"`
+	Accepted    bool         `json:"accepted"`
+	Deleted     bool         `json:"deleted"`
+	EditedAt    *time.Time   `json:"editedAt,omitempty"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	Replies     []CommentDTO `json:"replies,omitempty"`
+}
+
+var (
+	// cel linku z jednym poziomem zbalansowanych nawiasów ("alert(1)"); bez pary — do pierwszego ')'
+	inlineLinkRe = regexp.MustCompile(`\]\(((?:[^()]|\([^()]*\))*|[^)]*)\)`)
+	// definicja referencyjna "[x]: url", cel także w następnej linii
+	refDefRe  = regexp.MustCompile(`(?m)^( {0,3}\[[^\]\n]+\]:)[ \t]*(?:\n[ \t]*)?(\S.*)$`)
+	schemeRe  = regexp.MustCompile(`^([a-z][a-z0-9+.\-]*):`)
+	controlRe = regexp.MustCompile("[\x00-\x08\x0B\x0C\x0E-\x1F\x7F]")
+)
+
+// safeLinkSchemes to dozwolone schematy celów linków; cel bez schematu (względny) też jest dozwolony.
+var safeLinkSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// safeLinkTarget mówi, czy cel linku (z ewentualnym tytułem) jest dozwolony. Sprawdza go tak,
+// jak zobaczy go przeglądarka: po zdekodowaniu encji (CommonMark dekoduje je w celach),
+// escape'ów '\' i bez białych znaków/znaków sterujących ("java&#x73;cript:", "java\tscript:").
+func safeLinkTarget(dest string) bool {
+	dest = html.UnescapeString(dest)
+	dest = strings.Map(func(r rune) rune {
+		if r == '\\' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, dest)
+	dest = strings.TrimLeft(strings.ToLower(dest), "<")
+	m := schemeRe.FindStringSubmatch(dest)
+	return m == nil || safeLinkSchemes[m[1]]
+}
+
+// sanitizeMarkdown: podstawowa sanityzacja markdown — bez surowego HTML (escapujemy '<'),
+// bez znaków sterujących, a linki (inline i definicje referencyjne "[x]: url") tylko do
+// http(s)://, mailto: albo względne; pozostałe dostają cel "#".
+func sanitizeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = controlRe.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "<", "&lt;")
+	s = inlineLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		if safeLinkTarget(inlineLinkRe.FindStringSubmatch(m)[1]) {
+			return m
+		}
+		return "](#)"
+	})
+	s = refDefRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := refDefRe.FindStringSubmatch(m)
+		if safeLinkTarget(sub[2]) {
+			return m
+		}
+		return sub[1] + " #"
+	})
+	return strings.TrimSpace(s)
+}
+
+// commentCounts zwraca liczbę (nieusuniętych) komentarzy per pytanie.
+func commentCounts(db *gorm.DB, qids []string) (map[string]int, error) {
+	out := map[string]int{}
+	if len(qids) == 0 {
+		return out, nil
+	}
+	type Row struct {
+		QuestionID string
+		C          int
+	}
+	var rows []Row
+	if err := db.Model(&Comment{}).
+		Select("question_id, COUNT(*) as c").
+		Where("question_id IN ? AND deleted = ?", qids, false).
+		Group("question_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.QuestionID] = r.C
+	}
+	return out, nil
+}
+
+// commentRateLimited sprawdza limity (minuta / doba) na podstawie komentarzy w DB.
+func commentRateLimited(db *gorm.DB, uid uint) (bool, error) {
+	now := time.Now()
+	var lastMinute, lastDay int64
+	if err := db.Model(&Comment{}).Where("user_id = ? AND created_at >= ?", uid, now.Add(-commentRateWindow)).Count(&lastMinute).Error; err != nil {
+		return false, err
+	}
+	if err := db.Model(&Comment{}).Where("user_id = ? AND created_at >= ?", uid, now.Add(-24*time.Hour)).Count(&lastDay).Error; err != nil {
+		return false, err
+	}
+	return lastMinute >= commentRateMax || lastDay >= commentDailyMax, nil
+}
+
+// GET /api/v1/questions/:id/comments
+// Wątki: komentarze główne (najnowsze na górze) z odpowiedziami (chronologicznie).
+func ListComments(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := currentUserID(c)
+		qid := c.Param("id")
+		var cs []Comment
+		if err := db.Where("question_id = ?", qid).Order("created_at").Find(&cs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+
+		authorIDs := make([]uint, 0, len(cs))
+		commentIDs := make([]uint, 0, len(cs))
+		for _, cm := range cs {
+			authorIDs = append(authorIDs, cm.UserID)
+			commentIDs = append(commentIDs, cm.ID)
+		}
+		names := map[uint]string{}
+		if len(authorIDs) > 0 {
+			var us []User
+			_ = db.Where("id IN ?", authorIDs).Find(&us).Error
+			for _, u := range us {
+				if u.DisplayName != nil {
+					names[u.ID] = *u.DisplayName
+				}
+			}
+		}
+		myVotes := map[uint]bool{}
+		if uid != 0 && len(commentIDs) > 0 {
+			var vs []CommentVote
+			_ = db.Where("user_id = ? AND comment_id IN ?", uid, commentIDs).Find(&vs).Error
+			for _, v := range vs {
+				myVotes[v.CommentID] = true
+			}
+		}
+
+		toDTO := func(cm Comment) CommentDTO {
+			d := CommentDTO{
+				ID: cm.ID, ParentID: cm.ParentID, Author: "anonymous", Mine: cm.UserID == uid,
+				Body: cm.Body, Upvotes: cm.Upvotes, UpvotedByMe: myVotes[cm.ID], Accepted: cm.Accepted,
+				Deleted: cm.Deleted, EditedAt: cm.EditedAt, CreatedAt: cm.CreatedAt,
+			}
+			if n, ok := names[cm.UserID]; ok {
+				d.Author = n
+			}
+			if cm.Deleted {
+				d.Author, d.Body = "", ""
+			}
+			return d
+		}
+
+		replies := map[uint][]CommentDTO{}
+		for _, cm := range cs {
+			if cm.ParentID != nil {
+				replies[*cm.ParentID] = append(replies[*cm.ParentID], toDTO(cm))
+			}
+		}
+		threads := []CommentDTO{}
+		for i := len(cs) - 1; i >= 0; i-- {
+			cm := cs[i]
+			if cm.ParentID != nil {
+				continue
+			}
+			d := toDTO(cm)
+			d.Replies = replies[cm.ID]
+			// usunięty wątek bez odpowiedzi nie ma czego pokazywać
+			if cm.Deleted && len(d.Replies) == 0 {
+				continue
+			}
+			threads = append(threads, d)
+		}
+		c.JSON(http.StatusOK, gin.H{"questionId": qid, "threads": threads})
+	}
+}
+
+// POST /api/v1/questions/:id/comments
+func CreateComment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		var q Question
+		if err := db.First(&q, "id = ?", c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "question not found"})
+			return
+		}
+		var req CommentReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		body := sanitizeMarkdown(req.Body)
+		if body == "" || len(body) > commentMaxLen {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "body must be 1..5000 chars"})
+			return
+		}
+		limited, err := commentRateLimited(db, uid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		if limited {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many comments, try again later"})
+			return
+		}
+
+		cm := Comment{QuestionID: q.ID, UserID: uid, Body: body}
+		if req.ParentID != nil {
+			var parent Comment
+			if err := db.First(&parent, "id = ? AND question_id = ?", *req.ParentID, q.ID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parent comment not found"})
+				return
+			}
+			// wątki są dwupoziomowe: odpowiedź na odpowiedź trafia do wątku głównego
+			root := parent.ID
+			if parent.ParentID != nil {
+				root = *parent.ParentID
+			}
+			cm.ParentID = &root
+		}
+		if err := db.Create(&cm).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": cm.ID, "parentId": cm.ParentID, "body": cm.Body, "createdAt": cm.CreatedAt})
+	}
+}
+
+// loadComment ładuje komentarz z :id; błędy zapisuje do odpowiedzi.
+func loadComment(c *gin.Context, db *gorm.DB) (*Comment, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad comment id"})
+		return nil, false
+	}
+	var cm Comment
+	if err := db.First(&cm, id).Error; err != nil || cm.Deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		return nil, false
+	}
+	return &cm, true
+}
+
+// PUT /api/v1/comments/:id — tylko własne
+func UpdateComment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		cm, ok := loadComment(c, db)
+		if !ok {
+			return
+		}
+		if cm.UserID != uid {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		var req CommentReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		body := sanitizeMarkdown(req.Body)
+		if body == "" || len(body) > commentMaxLen {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "body must be 1..5000 chars"})
+			return
+		}
+		now := time.Now()
+		cm.Body, cm.EditedAt = body, &now
+		if err := db.Save(cm).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": cm.ID, "body": cm.Body, "editedAt": cm.EditedAt})
+	}
+}
+
+// DELETE /api/v1/comments/:id — własne albo admin (soft delete)
+func DeleteComment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		cm, ok := loadComment(c, db)
+		if !ok {
+			return
+		}
+		if cm.UserID != uid && !isAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		if err := db.Model(cm).Updates(map[string]interface{}{"deleted": true, "body": "", "accepted": false}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+	}
+}
+
+// POST/DELETE /api/v1/comments/:id/upvote — idempotentne dodanie/usunięcie głosu
+func VoteComment(db *gorm.DB, up bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		cm, ok := loadComment(c, db)
+		if !ok {
+			return
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var v CommentVote
+			err := tx.First(&v, "comment_id = ? AND user_id = ?", cm.ID, uid).Error
+			exists := err == nil
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			switch {
+			case up && !exists:
+				if err := tx.Create(&CommentVote{CommentID: cm.ID, UserID: uid}).Error; err != nil {
+					return err
+				}
+				return tx.Model(cm).Update("upvotes", gorm.Expr("upvotes + 1")).Error
+			case !up && exists:
+				if err := tx.Delete(&v).Error; err != nil {
+					return err
+				}
+				return tx.Model(cm).Update("upvotes", gorm.Expr("upvotes - 1")).Error
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		var fresh Comment
+		_ = db.First(&fresh, cm.ID).Error
+		c.JSON(http.StatusOK, gin.H{"id": cm.ID, "upvotes": fresh.Upvotes, "upvotedByMe": up})
+	}
+}
+
+// POST /api/v1/comments/:id/accept
+// Autor wątku (albo admin) oznacza odpowiedź jako zaakceptowaną; max jedna na wątek.
+func AcceptComment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		cm, ok := loadComment(c, db)
+		if !ok {
+			return
+		}
+		if cm.ParentID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "only replies can be accepted"})
+			return
+		}
+		var root Comment
+		if err := db.First(&root, *cm.ParentID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+			return
+		}
+		if root.UserID != uid && !isAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only thread author or admin can accept"})
+			return
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&Comment{}).Where("parent_id = ?", root.ID).Update("accepted", false).Error; err != nil {
+				return err
+			}
+			return tx.Model(cm).Update("accepted", true).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": cm.ID, "accepted": true})
+	}
+}
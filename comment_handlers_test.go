@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestSanitizeMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain markdown kept", in: "  **b** is right, see [docs](https://help.sap.com)\n> quote ", want: "**b** is right, see [docs](https://help.sap.com)\n> quote"},
+		{name: "raw html escaped", in: "<script>alert(1)</script>", want: "&lt;script>alert(1)&lt;/script>"},
+		{name: "javascript link neutralized", in: "[x](JavaScript:alert(1)", want: "[x](#)"},
+		{name: "balanced parens in link", in: "[x](javascript:alert(1)) after", want: "[x](#) after"},
+		{name: "reference definition neutralized", in: "[x]\n\n[x]: javascript:alert(1)\n [y]:  VBScript:msgbox", want: "[x]\n\n[x]: #\n [y]: #"},
+		{name: "safe reference definition kept", in: "[x]: https://help.sap.com", want: "[x]: https://help.sap.com"},
+		{name: "decimal entity in scheme", in: "[x](&#106;avascript:alert(1))", want: "[x](#)"},
+		{name: "hex entity in scheme", in: "[x](java&#x73;cript:alert(1))", want: "[x](#)"},
+		{name: "named entity colon", in: "[x](javascript&colon;alert(1))", want: "[x](#)"},
+		{name: "whitespace inside scheme", in: "[x](java&#9;script:alert(1))", want: "[x](#)"},
+		{name: "entity in reference definition", in: "[x]: &#106;avascript:alert(1)", want: "[x]: #"},
+		{name: "reference definition on next line", in: "[x]:\n  javascript:alert(1)", want: "[x]: #"},
+		{name: "unknown scheme neutralized", in: "[x](file:///etc/passwd)", want: "[x](#)"},
+		{name: "mailto and relative kept", in: "[m](mailto:a@b.c) [r](/questions/cx:001 \"title\")", want: "[m](mailto:a@b.c) [r](/questions/cx:001 \"title\")"},
+		{name: "data link neutralized", in: "[x]( data:text/html;base64,AAA)", want: "[x](#)"},
+		{name: "control chars stripped", in: "a\x00b\r\nc", want: "ab\nc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeMarkdown(tt.in); got != tt.want {
+				t.Errorf("sanitizeMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -10,21 +10,33 @@ func OpenDB(path string) (*gorm.DB, error) {
 }
 
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	// przed unikalnym indeksem idx_answer_exam_question
+	if err := dedupeExamAnswers(db); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(
 		&User{},        // nowy model użytkownika
+		&AccountMerge{},
+		&DeletionToken{},
+		&Group{},
+		&GroupMember{},
+		&Assignment{},
+		&AssignmentQuestion{},
+		&LeaderboardEntry{},
+		&QuestionBank{},
 		&Question{},
 		&Option{},
 		&Explanation{},
+		&QuestionReport{},
+		&Comment{},
+		&CommentVote{},
+		&QuestionProposal{},
 		&Exam{},
 		&ExamQuestion{},
+		&ExamPause{},
 		&Answer{},
-	)
-}
-
-func IsQuestionTableEmpty(db *gorm.DB) (bool, error) {
-	var count int64
-	if err := db.Model(&Question{}).Count(&count).Error; err != nil {
-		return false, err
+	); err != nil {
+		return err
 	}
-	return count == 0, nil
+	return backfillExamStatus(db) // egzaminy sprzed Exam.Status
 }
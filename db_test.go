@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// newTestDB otwiera czystą bazę SQLite w pamięci z pełną migracją.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := OpenDB(":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	// każde połączenie do :memory: to osobna baza
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
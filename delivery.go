@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Tryb sekwencyjny: StartExam nie zwraca pytań, klient pobiera je pojedynczo
+// przez GET /exams/:id/next. Exam.CurrentPosition to najdalsze wydane pytanie.
+
+const (
+	DeliveryAll        = "all"        // wszystkie pytania w odpowiedzi StartExam (domyślnie)
+	DeliverySequential = "sequential" // jedno pytanie naraz
+)
+
+// examDelivery zwraca tryb egzaminu; pusty (egzaminy sprzed trybów) = all.
+func examDelivery(e Exam) string {
+	if e.Delivery == "" {
+		return DeliveryAll
+	}
+	return e.Delivery
+}
+
+type SequentialQuestion struct {
+	Position      int         `json:"position"`
+	QuestionCount int         `json:"questionCount"`
+	Question      QuestionDTO `json:"question"`
+	Answered      bool        `json:"answered"`
+	Selected      []string    `json:"selected,omitempty"` // przy powrocie do pytania z odpowiedzią
+	Locked        bool        `json:"locked"`             // odpowiedzi nie można już zmienić
+	Clock         ExamClock   `json:"clock"`
+}
+
+var errNotSequential = errors.New("exam does not use sequential delivery")
+
+// loadSequentialExam ładuje egzamin w trybie sekwencyjnym, jeszcze trwający i niezapauzowany.
+func loadSequentialExam(c *gin.Context, db *gorm.DB) (*Exam, bool) {
+	exam, ok := loadOwnExam(c, db)
+	if !ok {
+		return nil, false
+	}
+	if examDelivery(*exam) != DeliverySequential {
+		c.JSON(http.StatusConflict, gin.H{"error": errNotSequential.Error()})
+		return nil, false
+	}
+	if !rejectIfClosed(c, db, exam) {
+		return nil, false
+	}
+	if !rejectIfPaused(c, db, exam) {
+		return nil, false
+	}
+	return exam, true
+}
+
+// firstAnswer zwraca odpowiedź na pytanie w egzaminie albo nil.
+func firstAnswer(db *gorm.DB, examID, qid string) (*Answer, error) {
+	var a Answer
+	err := db.Where("exam_id = ? AND question_id = ?", examID, qid).Order("id").First(&a).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// serveQuestion oddaje pytanie z pozycji eq i otwiera wizytę (pomiar czasu jak POST .../view).
+func serveQuestion(c *gin.Context, db *gorm.DB, exam *Exam, eq ExamQuestion) {
+	var count int64
+	if err := db.Model(&ExamQuestion{}).Where("exam_id = ?", exam.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	dtos, err := loadQuestionDTOs(db, []string{eq.QuestionID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	ans, err := firstAnswer(db, exam.ID, eq.QuestionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	if err := db.Model(&eq).Update("viewed_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	clock, err := examClock(db, exam)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	out := SequentialQuestion{Position: eq.Position, QuestionCount: int(count), Question: dtos[0], Clock: clock}
+	if ans != nil {
+		out.Answered = true
+		_ = json.Unmarshal([]byte(ans.SelectedRaw), &out.Selected)
+		out.Locked = exam.LockAnswered
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GET /api/v1/exams/:id/next
+// Oddaje bieżące pytanie, dopóki nie ma odpowiedzi (odświeżenie nie przeskakuje pytania);
+// po odpowiedzi przechodzi do kolejnego. Pominięcie = odpowiedź z pustym selected.
+func NextExamQuestion(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exam, ok := loadSequentialExam(c, db)
+		if !ok {
+			return
+		}
+		pos := exam.CurrentPosition
+		if pos > 0 {
+			var cur ExamQuestion
+			if err := db.Where("exam_id = ? AND position = ?", exam.ID, pos).First(&cur).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			ans, err := firstAnswer(db, exam.ID, cur.QuestionID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			if ans == nil {
+				serveQuestion(c, db, exam, cur)
+				return
+			}
+		}
+
+		var next ExamQuestion
+		err := db.Where("exam_id = ? AND position = ?", exam.ID, pos+1).First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusOK, gin.H{"done": true, "questionCount": pos})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		if err := db.Model(exam).Update("current_position", next.Position).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		serveQuestion(c, db, exam, next)
+	}
+}
+
+// GET /api/v1/exams/:id/questions/:qid — powrót do już wydanego pytania (allowBack)
+func GetExamQuestion(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exam, ok := loadSequentialExam(c, db)
+		if !ok {
+			return
+		}
+		var eq ExamQuestion
+		if err := db.Where("exam_id = ? AND question_id = ?", exam.ID, c.Param("qid")).First(&eq).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "question not in exam"})
+			return
+		}
+		if msg := sequentialAccess(exam, eq.Position); msg != "" {
+			c.JSON(http.StatusConflict, gin.H{"error": msg})
+			return
+		}
+		serveQuestion(c, db, exam, eq)
+	}
+}
+
+// sequentialAccess sprawdza, czy pytanie z pozycji pos jest dostępne; "" = tak.
+func sequentialAccess(exam *Exam, pos int) string {
+	switch {
+	case pos > exam.CurrentPosition:
+		return "question not served yet"
+	case pos < exam.CurrentPosition && !exam.AllowBack:
+		return "back navigation is disabled for this exam"
+	}
+	return ""
+}
+
+// checkExamAnswer sprawdza odpowiedź w każdym trybie: pytanie musi należeć do egzaminu,
+// a w trybie sekwencyjnym być dostępne. Zwraca wcześniejszą odpowiedź (do nadpisania —
+// jedna odpowiedź na pytanie) i komunikat 409 ("" = można odpowiadać).
+func checkExamAnswer(db *gorm.DB, exam *Exam, qid string) (*Answer, string, error) {
+	var eq ExamQuestion
+	if err := db.Where("exam_id = ? AND question_id = ?", exam.ID, qid).First(&eq).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "question not in exam", nil
+		}
+		return nil, "", err
+	}
+	if examDelivery(*exam) == DeliverySequential {
+		if msg := sequentialAccess(exam, eq.Position); msg != "" {
+			return nil, msg, nil
+		}
+	}
+	prev, err := firstAnswer(db, exam.ID, qid)
+	if err != nil {
+		return nil, "", err
+	}
+	if prev != nil && exam.LockAnswered {
+		return nil, "answered questions are locked for this exam", nil
+	}
+	return prev, "", nil
+}
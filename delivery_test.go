@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckExamAnswer(t *testing.T) {
+	db := newTestDB(t)
+	exam := Exam{ID: "e1", Type: "exam", StartedAt: time.Now(), DurationSeconds: 60, Delivery: DeliverySequential, CurrentPosition: 2}
+	db.Create(&exam)
+	for i, q := range []string{"q1", "q2", "q3"} {
+		db.Create(&ExamQuestion{ExamID: "e1", QuestionID: q, Position: i + 1})
+	}
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: `["a"]`})
+
+	check := func(qid string) (*Answer, string) {
+		t.Helper()
+		prev, msg, err := checkExamAnswer(db, &exam, qid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return prev, msg
+	}
+	if _, msg := check("q3"); msg != "question not served yet" {
+		t.Errorf("unserved question: %q", msg)
+	}
+	if _, msg := check("zz"); msg != "question not in exam" {
+		t.Errorf("foreign question: %q", msg)
+	}
+	if prev, msg := check("q2"); msg != "" || prev != nil {
+		t.Errorf("current question: prev=%v msg=%q", prev, msg)
+	}
+	if _, msg := check("q1"); msg != "back navigation is disabled for this exam" {
+		t.Errorf("earlier question without allowBack: %q", msg)
+	}
+
+	exam.AllowBack = true
+	if prev, msg := check("q1"); msg != "" || prev == nil {
+		t.Errorf("allowBack: prev=%v msg=%q, want earlier answer to overwrite", prev, msg)
+	}
+	exam.LockAnswered = true
+	if _, msg := check("q1"); msg != "answered questions are locked for this exam" {
+		t.Errorf("lockAnswered: %q", msg)
+	}
+}
+
+func TestExamAnswerOverwritesInEveryDelivery(t *testing.T) {
+	f := newAccessFixture(t)
+	f.db.Create(&Question{ID: "q2", TextEN: "q2"}) // w banku, ale nie w egzaminie
+	f.db.Create(&Option{QuestionID: "q2", OptionKey: "a", TextEN: "a", IsCorrect: true})
+	f.exam(t, "e1", "", false)
+
+	if w := f.do("POST", "/api/v1/exams/e1/answer?questionId=q2", `{"selected":["a"]}`, f.owner); w.Code != http.StatusConflict {
+		t.Errorf("question outside the exam: %d %s, want 409", w.Code, w.Body.String())
+	}
+	for _, sel := range []string{"b", "a", "a"} {
+		if w := f.do("POST", "/api/v1/exams/e1/answer?questionId=q1", `{"selected":["`+sel+`"]}`, f.owner); w.Code != http.StatusOK {
+			t.Fatalf("answer %s: %d %s", sel, w.Code, w.Body.String())
+		}
+	}
+	var answers []Answer
+	f.db.Where("exam_id = ?", "e1").Find(&answers)
+	if len(answers) != 1 || answers[0].QuestionID != "q1" || answers[0].SelectedRaw != `["a"]` {
+		t.Errorf("answers = %+v, want one overwritten answer to q1", answers)
+	}
+}
+
+func TestGetUnfinishedSequentialExamHidesAnswerKey(t *testing.T) {
+	f := newAccessFixture(t)
+	for _, id := range []string{"q2", "q3"} {
+		f.db.Create(&Question{ID: id, TextEN: id})
+		f.db.Create(&Option{QuestionID: id, OptionKey: "a", TextEN: "a", IsCorrect: true})
+		f.db.Create(&Explanation{QuestionID: id, OptionKey: "a", Lang: "en", Text: "because"})
+	}
+	seed := int64(1)
+	e := Exam{ID: "e1", Type: "exam", UserID: &f.owner.ID, StartedAt: time.Now(), DurationSeconds: 3600,
+		Seed: &seed, Delivery: DeliverySequential, CurrentPosition: 2}
+	if err := createExam(f.db, &e, []string{"q1", "q2", "q3"}); err != nil {
+		t.Fatal(err)
+	}
+	f.db.Create(&Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: `["b"]`, AnsweredAt: time.Now()})
+
+	w := f.do("GET", "/api/v1/exams/e1", "", f.owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Items) != 2 {
+		t.Fatalf("got %d items, want the 2 served questions: %s", len(body.Items), w.Body.String())
+	}
+	for _, it := range body.Items {
+		if it["questionId"] == "q3" {
+			t.Error("question not served yet is in the review")
+		}
+		for _, key := range []string{"correct", "wasCorrect", "points", "explanationsEn", "explanationsPl"} {
+			if _, ok := it[key]; ok {
+				t.Errorf("%v: %q leaked before finish", it["questionId"], key)
+			}
+		}
+	}
+	if strings.Contains(w.Body.String(), `"correct"`) || strings.Contains(w.Body.String(), "because") {
+		t.Errorf("answer key leaked: %s", w.Body.String())
+	}
+}
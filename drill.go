@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Drill: krótka sesja (domyślnie 10 pytań) z natychmiastowym feedbackiem po każdej
+// odpowiedzi, jak w /learn/answer. Zapisywana jako Exam z Type="drill";
+// w statystykach liczona osobno od egzaminów.
+const (
+	ExamTypeDrill = "drill"
+
+	drillDefaultCount    = 10
+	drillMaxCount        = 50
+	drillMinQuestionSec  = 10
+	drillMaxQuestionSec  = 600
+	drillUntimedSecPerQ  = 120 // czas sesji na pytanie, gdy bez timera
+	drillWeakestTagLimit = 3
+)
+
+// startDrill tworzy sesję drill i zwraca od razu wszystkie pytania.
+func startDrill(c *gin.Context, db *gorm.DB, req StartExamReq, bank *QuestionBank, userID *uint) {
+	if req.Count > drillMaxCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "drill count must be at most 50"})
+		return
+	}
+	if req.QuestionTimeSec != 0 && (req.QuestionTimeSec < drillMinQuestionSec || req.QuestionTimeSec > drillMaxQuestionSec) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "questionTimeSec must be 0 or between 10 and 600"})
+		return
+	}
+	var ids []string
+	if err := db.Model(&Question{}).Where("bank_id = ?", bank.ID).Order("id").Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no questions"})
+		return
+	}
+	drawn := drawQuestions(ids, req.Count, req.Seed)
+
+	perQuestion := req.QuestionTimeSec
+	if perQuestion == 0 {
+		perQuestion = drillUntimedSecPerQ
+	}
+	exam := Exam{
+		ID:              uuid.New().String(),
+		Type:            ExamTypeDrill,
+		StartedAt:       time.Now(),
+		DurationSeconds: perQuestion * len(drawn),
+		Seed:            req.Seed,
+		UserID:          userID,
+		BankID:          bank.ID,
+		Scoring:         req.Scoring,
+		MaxPauseSeconds: *req.MaxPauseSec,
+		QuestionTimeSec: req.QuestionTimeSec,
+	}
+	if err := createExam(db, &exam, drawn); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	out, err := loadQuestionDTOs(db, drawn)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"examId":          exam.ID,
+		"type":            exam.Type,
+		"bank":            bank.ID,
+		"durationSec":     exam.DurationSeconds,
+		"questionTimeSec": exam.QuestionTimeSec,
+		"scoring":         exam.Scoring,
+		"questions":       out,
+		"clock":           computeClock(exam, nil, time.Now()),
+	})
+}
+
+// drillTimedOut mówi, czy zmierzony czas przekroczył timer pytania (bez timera/pomiaru: nie).
+func drillTimedOut(e *Exam, spentMs *int64) bool {
+	return e.QuestionTimeSec > 0 && spentMs != nil && *spentMs > int64(e.QuestionTimeSec)*1000
+}
+
+// drillElapsedMs to czas pytania zmierzony przez serwer, dla timera drilla: od /view, a bez
+// niego od ostatniego zdarzenia sesji (start, poprzednia odpowiedź, koniec pauzy).
+// Woła się go przed measureTimeSpent, który zamyka wizytę.
+func drillElapsedMs(db *gorm.DB, exam *Exam, qid string, now time.Time) (int64, error) {
+	var eq ExamQuestion
+	if err := db.Where("exam_id = ? AND question_id = ?", exam.ID, qid).First(&eq).Error; err != nil {
+		return 0, err
+	}
+	if eq.ViewedAt != nil {
+		return now.Sub(*eq.ViewedAt).Milliseconds(), nil
+	}
+	from := exam.StartedAt
+	var last []Answer
+	if err := db.Where("exam_id = ?", exam.ID).Order("answered_at DESC").Limit(1).Find(&last).Error; err != nil {
+		return 0, err
+	}
+	if len(last) > 0 && last[0].AnsweredAt.After(from) {
+		from = last[0].AnsweredAt
+	}
+	var resumed []ExamPause
+	if err := db.Where("exam_id = ? AND resumed_at IS NOT NULL", exam.ID).Order("resumed_at DESC").Limit(1).Find(&resumed).Error; err != nil {
+		return 0, err
+	}
+	if len(resumed) > 0 && resumed[0].ResumedAt.After(from) {
+		from = *resumed[0].ResumedAt
+	}
+	return now.Sub(from).Milliseconds(), nil
+}
+
+// drillAnswer zapisuje odpowiedź w sesji drill i od razu zwraca feedback.
+// Każde pytanie można zaliczyć raz; odpowiedź po czasie liczy się jako błędna.
+func drillAnswer(c *gin.Context, db *gorm.DB, exam *Exam, qid string, prev *Answer, req ExamAnswerReq, opts []Option) {
+	if prev != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "question already answered"})
+		return
+	}
+
+	now := time.Now()
+	elapsed, err := drillElapsedMs(db, exam, qid, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	spent, err := measureTimeSpent(db, exam, qid, req.TimeSpentMs, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	// timer liczy czas serwera; czas klienta co najwyżej do niego, a po przekroczeniu
+	// zapisujemy czas serwera, żeby podsumowanie widziało timeout
+	timedOut := drillTimedOut(exam, &elapsed)
+	if spent == nil || *spent > elapsed || timedOut {
+		spent = reportedTime(exam, &elapsed)
+	}
+	correct := correctKeysOf(opts)
+	ok := !timedOut && isCorrectAllOrNothing(req.Selected, correct)
+	points := 0.0
+	if !timedOut {
+		points = scoreAnswer(examScoring(*exam), req.Selected, correct, len(opts))
+	}
+	raw, _ := json.Marshal(req.Selected)
+	ans := Answer{
+		ExamID:      exam.ID,
+		QuestionID:  qid,
+		SelectedRaw: string(raw),
+		IsCorrect:   ok,
+		AnsweredAt:  now,
+		TimeSpentMs: spent,
+		Points:      &points,
+	}
+	if err := saveExamAnswer(db, &ans); errors.Is(err, errAlreadyFinished) {
+		c.JSON(http.StatusConflict, gin.H{"error": "exam already finished"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+
+	expl, err := loadExplanations(db, qid, c.Query("lang"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	var total, answered, right int64
+	if err := db.Model(&ExamQuestion{}).Where("exam_id = ?", exam.ID).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	db.Model(&Answer{}).Where("exam_id = ?", exam.ID).Count(&answered)
+	db.Model(&Answer{}).Where("exam_id = ? AND is_correct = ?", exam.ID, true).Count(&right)
+
+	c.JSON(http.StatusOK, gin.H{
+		"saved":            true,
+		"isCorrect":        ok,
+		"timedOut":         timedOut,
+		"points":           points,
+		"correctOptionIds": correct,
+		"explanations":     expl,
+		"timeSpentMs":      spent,
+		"progress":         gin.H{"answered": answered, "correct": right, "total": total, "done": answered >= total},
+	})
+}
+
+type DrillSummary struct {
+	QuestionCount   int                 `json:"questionCount"`
+	Answered        int                 `json:"answered"`
+	Correct         int                 `json:"correct"`
+	Skipped         int                 `json:"skipped"` // bez odpowiedzi albo z pustym wyborem
+	TimedOut        int                 `json:"timedOut"`
+	AccuracyPercent *float64            `json:"accuracyPercent,omitempty"` // poprawne / udzielone odpowiedzi
+	AvgTimeMs       *float64            `json:"avgTimeMs,omitempty"`
+	Tags            map[string]TagScore `json:"tags"`
+	WeakestTags     []string            `json:"weakestTags"` // najniższa skuteczność, max 3
+}
+
+// summarizeDrill składa podsumowanie sesji z review i wyników per tag.
+func summarizeDrill(e *Exam, review []ReviewRow, tags map[string]*answerCount) DrillSummary {
+	s := DrillSummary{QuestionCount: len(review), Tags: map[string]TagScore{}, WeakestTags: []string{}}
+	var timeSum int64
+	timed := 0
+	for _, r := range review {
+		if len(r.Selected) == 0 {
+			s.Skipped++
+			continue
+		}
+		s.Answered++
+		if r.WasCorrect {
+			s.Correct++
+		}
+		if r.TimeSpentMs != nil {
+			timeSum += *r.TimeSpentMs
+			timed++
+			if drillTimedOut(e, r.TimeSpentMs) {
+				s.TimedOut++
+			}
+		}
+	}
+	if s.Answered > 0 {
+		acc := float64(s.Correct) * 100 / float64(s.Answered)
+		s.AccuracyPercent = &acc
+	}
+	if timed > 0 {
+		avg := float64(timeSum) / float64(timed)
+		s.AvgTimeMs = &avg
+	}
+	for tag, tc := range tags {
+		s.Tags[tag] = TagScore{Answered: tc.Total, Correct: tc.Correct, ScorePercent: tc.Accuracy()}
+		if tc.Correct < tc.Total {
+			s.WeakestTags = append(s.WeakestTags, tag)
+		}
+	}
+	sort.Slice(s.WeakestTags, func(i, j int) bool {
+		a, b := s.Tags[s.WeakestTags[i]], s.Tags[s.WeakestTags[j]]
+		if *a.ScorePercent != *b.ScorePercent {
+			return *a.ScorePercent < *b.ScorePercent
+		}
+		return s.WeakestTags[i] < s.WeakestTags[j]
+	})
+	if len(s.WeakestTags) > drillWeakestTagLimit {
+		s.WeakestTags = s.WeakestTags[:drillWeakestTagLimit]
+	}
+	return s
+}
+
+// drillSummaryOf liczy podsumowanie sesji drill; nil dla innych typów egzaminu.
+func drillSummaryOf(db *gorm.DB, e *Exam, review []ReviewRow) (*DrillSummary, error) {
+	if e.Type != ExamTypeDrill {
+		return nil, nil
+	}
+	tags, err := examTagScores(db, []string{e.ID})
+	if err != nil {
+		return nil, err
+	}
+	s := summarizeDrill(e, review, tags[e.ID])
+	return &s, nil
+}
+
+// loadExplanations zwraca wyjaśnienia opcji pytania w danym języku (en domyślnie).
+func loadExplanations(db *gorm.DB, qid, lang string) (map[string]ExpDTO, error) {
+	if strings.ToLower(lang) == "pl" {
+		lang = "pl"
+	} else {
+		lang = "en"
+	}
+	var expl []Explanation
+	if err := db.Where("question_id = ? AND lang = ?", qid, lang).Find(&expl).Error; err != nil {
+		return nil, err
+	}
+	byKey := map[string]ExpDTO{}
+	for _, e := range expl {
+		byKey[e.OptionKey] = ExpDTO{Text: e.Text, URL: e.URL}
+	}
+	return byKey, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDrillTimedOut(t *testing.T) {
+	ms := func(v int64) *int64 { return &v }
+	timed := &Exam{QuestionTimeSec: 30}
+	untimed := &Exam{}
+	if !drillTimedOut(timed, ms(30001)) {
+		t.Error("answer after the timer should time out")
+	}
+	if drillTimedOut(timed, ms(30000)) || drillTimedOut(timed, nil) {
+		t.Error("answer within the timer (or unmeasured) timed out")
+	}
+	if drillTimedOut(untimed, ms(999999)) {
+		t.Error("drill without a timer timed out")
+	}
+}
+
+func TestSummarizeDrill(t *testing.T) {
+	ms := func(v int64) *int64 { return &v }
+	e := &Exam{Type: ExamTypeDrill, QuestionTimeSec: 20}
+	review := []ReviewRow{
+		{QuestionID: "q1", Selected: []string{"a"}, WasCorrect: true, TimeSpentMs: ms(10000)},
+		{QuestionID: "q2", Selected: []string{"b"}, TimeSpentMs: ms(25000)}, // po czasie
+		{QuestionID: "q3", Selected: []string{"c"}},
+		{QuestionID: "q4"}, // pominięte
+	}
+	tags := map[string]*answerCount{
+		"OMS":  {Total: 2, Correct: 1},
+		"SOLR": {Total: 1, Correct: 0},
+		"CMS":  {Total: 1, Correct: 1},
+	}
+	s := summarizeDrill(e, review, tags)
+	if s.QuestionCount != 4 || s.Answered != 3 || s.Correct != 1 || s.Skipped != 1 || s.TimedOut != 1 {
+		t.Errorf("counts: %+v", s)
+	}
+	if s.AccuracyPercent == nil || int(*s.AccuracyPercent) != 33 {
+		t.Errorf("accuracy = %v", s.AccuracyPercent)
+	}
+	if s.AvgTimeMs == nil || *s.AvgTimeMs != 17500 {
+		t.Errorf("avg time = %v", s.AvgTimeMs)
+	}
+	if !reflect.DeepEqual(s.WeakestTags, []string{"SOLR", "OMS"}) {
+		t.Errorf("weakest tags = %v", s.WeakestTags)
+	}
+}
+
+func TestStatsCountDrillsSeparately(t *testing.T) {
+	db := newTestDB(t)
+	uid := uint(1)
+	now := time.Now()
+	score := 70.0
+	drillScore := 100.0
+	db.Create(&Question{ID: "q1", TextEN: "q1"})
+	db.Create(&Exam{ID: "mock", Type: "exam", UserID: &uid, StartedAt: now, FinishedAt: &now, DurationSeconds: 3600, ScorePercent: &score, Status: ExamStatusFinished})
+	db.Create(&Exam{ID: "drill", Type: ExamTypeDrill, UserID: &uid, StartedAt: now, FinishedAt: &now, DurationSeconds: 1200, ScorePercent: &drillScore, Status: ExamStatusFinished})
+	db.Create(&Answer{ExamID: "drill", QuestionID: "q1", SelectedRaw: `["a"]`, IsCorrect: true, AnsweredAt: now})
+
+	st, err := computeStats(db, uid, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.TotalExams != 1 || st.CompletedExams != 1 || st.AverageScore == nil || *st.AverageScore != 70 {
+		t.Errorf("exam figures include the drill: total %d, completed %d, avg %v", st.TotalExams, st.CompletedExams, st.AverageScore)
+	}
+	if st.Drills.Sessions != 1 || st.Drills.Completed != 1 || st.Drills.Answers != 1 || st.Drills.Correct != 1 {
+		t.Errorf("drills: %+v", st.Drills)
+	}
+	if st.TotalAnswers != 0 || st.AccuracyOverall != nil || st.AnswersLast30d != 0 {
+		t.Errorf("answer totals include the drill: total %d, accuracy %v, last 30d %d", st.TotalAnswers, st.AccuracyOverall, st.AnswersLast30d)
+	}
+}
+
+func TestDrillTimerIgnoresClientTime(t *testing.T) {
+	f := newAccessFixture(t)
+	f.db.Create(&Question{ID: "q2", TextEN: "q2"})
+	f.db.Create(&Option{QuestionID: "q2", OptionKey: "a", TextEN: "a", IsCorrect: true})
+	start := time.Now().Add(-time.Minute)
+	e := Exam{ID: "d1", Type: ExamTypeDrill, UserID: &f.owner.ID, StartedAt: start, DurationSeconds: 600, QuestionTimeSec: 10}
+	if err := createExam(f.db, &e, []string{"q1", "q2"}); err != nil {
+		t.Fatal(err)
+	}
+	viewed := time.Now().Add(-20 * time.Second)
+	f.db.Model(&ExamQuestion{}).Where("exam_id = ? AND question_id = ?", "d1", "q1").Update("viewed_at", viewed)
+
+	type drillResp struct {
+		IsCorrect   bool   `json:"isCorrect"`
+		TimedOut    bool   `json:"timedOut"`
+		TimeSpentMs *int64 `json:"timeSpentMs"`
+	}
+	answer := func(qid string) drillResp {
+		t.Helper()
+		w := f.do("POST", "/api/v1/exams/d1/answer?questionId="+qid, `{"selected":["a"],"timeSpentMs":0}`, f.owner)
+		if w.Code != http.StatusOK {
+			t.Fatalf("answer %s: %d %s", qid, w.Code, w.Body.String())
+		}
+		var out drillResp
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	// q1: 20 s od /view przy timerze 10 s, mimo timeSpentMs 0 od klienta
+	if r := answer("q1"); !r.TimedOut || r.IsCorrect || r.TimeSpentMs == nil || *r.TimeSpentMs < 20000 {
+		t.Errorf("q1 = %+v, want timed out with the server time", r)
+	}
+	// q2 bez /view: od poprzedniej odpowiedzi, czyli w czasie; czas klienta zostaje
+	if r := answer("q2"); r.TimedOut || !r.IsCorrect || r.TimeSpentMs == nil || *r.TimeSpentMs != 0 {
+		t.Errorf("q2 = %+v, want in time with the client's 0 ms", r)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Dostęp do egzaminu z :id. Właściciel może wszystko; trener grupy, do której należy
+// właściciel, oraz admin tylko czytają (historia, porównanie). Każda akcja zmieniająca
+// egzamin (odpowiedź, finish, pauza, porzucenie, ...) wymaga właściciela.
+type examAccessMode int
+
+const (
+	examAccessRead examAccessMode = iota
+	examAccessWrite
+)
+
+// isTrainerOf mówi, czy trainerID jest trenerem w grupie, do której należy memberID.
+func isTrainerOf(db *gorm.DB, trainerID, memberID uint) (bool, error) {
+	var n int64
+	err := db.Table("group_members t").
+		Joins("JOIN group_members m ON m.group_id = t.group_id").
+		Where("t.user_id = ? AND t.role = ? AND m.user_id = ?", trainerID, GroupRoleTrainer, memberID).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// canAccessExam sprawdza dostęp bieżącego usera (uid) do egzaminu.
+func canAccessExam(c *gin.Context, db *gorm.DB, uid uint, exam *Exam, mode examAccessMode) (bool, error) {
+	if exam.UserID != nil && *exam.UserID == uid {
+		return true, nil
+	}
+	if mode != examAccessRead {
+		return false, nil
+	}
+	if isAdmin(c) {
+		return true, nil
+	}
+	if exam.UserID == nil {
+		return false, nil // egzamin zanonimizowany: tylko admin
+	}
+	return isTrainerOf(db, uid, *exam.UserID)
+}
+
+// loadExam ładuje egzamin o podanym ID i sprawdza dostęp; false = odpowiedź już wysłana
+// (401 bez usera, 404 brak egzaminu, 403 brak dostępu).
+func loadExam(c *gin.Context, db *gorm.DB, id string, mode examAccessMode) (*Exam, bool) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+		return nil, false
+	}
+	var exam Exam
+	if err := db.First(&exam, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exam not found"})
+		return nil, false
+	}
+	allowed, err := canAccessExam(c, db, uid, &exam, mode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return nil, false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return nil, false
+	}
+	return &exam, true
+}
+
+// loadOwnExam ładuje egzamin z :id do zmiany — tylko właściciel.
+func loadOwnExam(c *gin.Context, db *gorm.DB) (*Exam, bool) {
+	return loadExam(c, db, c.Param("id"), examAccessWrite)
+}
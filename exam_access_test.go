@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// accessFixture: właściciel egzaminu, obcy user, trener grupy właściciela i admin.
+type accessFixture struct {
+	db                           *gorm.DB
+	router                       *gin.Engine
+	owner, other, trainer, admin *User
+}
+
+func newAccessFixture(t *testing.T) *accessFixture {
+	t.Helper()
+	db := newTestDB(t)
+	f := &accessFixture{db: db}
+	for i, u := range []**User{&f.owner, &f.other, &f.trainer, &f.admin} {
+		*u = &User{PublicID: fmt.Sprintf("user-%d", i+1)}
+	}
+	f.admin.Role = RoleAdmin
+	for _, u := range []*User{f.owner, f.other, f.trainer, f.admin} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	g := Group{Name: "cohort", InviteCode: "abc", OwnerID: f.trainer.ID}
+	db.Create(&g)
+	db.Create(&GroupMember{GroupID: g.ID, UserID: f.trainer.ID, Role: GroupRoleTrainer, JoinedAt: time.Now()})
+	db.Create(&GroupMember{GroupID: g.ID, UserID: f.owner.ID, Role: GroupRoleMember, JoinedAt: time.Now()})
+
+	db.Create(&Question{ID: "q1", TextEN: "q1"})
+	db.Create(&Option{QuestionID: "q1", OptionKey: "a", TextEN: "a", IsCorrect: true})
+	db.Create(&Option{QuestionID: "q1", OptionKey: "b", TextEN: "b"})
+
+	// trasy egzaminów jak w main.go
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api/v1", LoadUser(db))
+	api.POST("/exams", StartExam(db))
+	api.POST("/exams/:id/questions/:qid/view", ViewExamQuestion(db))
+	api.GET("/exams/:id/next", NextExamQuestion(db))
+	api.GET("/exams/:id/questions/:qid", GetExamQuestion(db))
+	api.POST("/exams/:id/answer", ExamAnswer(db))
+	api.POST("/exams/:id/finish", FinishExam(db))
+	api.POST("/exams/:id/pause", PauseExam(db))
+	api.POST("/exams/:id/resume", ResumeExam(db))
+	api.POST("/exams/:id/abandon", AbandonExam(db))
+	api.DELETE("/exams/:id", DeleteExam(db))
+	api.POST("/exams/:id/retake", RetakeExam(db))
+	api.GET("/exams/:id", GetMyExam(db))
+	api.GET("/exams/:id/compare/:otherId", CompareExams(db))
+	f.router = r
+	return f
+}
+
+// exam tworzy egzamin właściciela z jednym pytaniem (q1).
+func (f *accessFixture) exam(t *testing.T, id, delivery string, finished bool) Exam {
+	t.Helper()
+	seed := int64(1)
+	e := Exam{
+		ID: id, Type: "exam", UserID: &f.owner.ID, StartedAt: time.Now(), DurationSeconds: 3600,
+		Seed: &seed, Delivery: delivery, MaxPauseSeconds: 600, CurrentPosition: 1,
+	}
+	if finished {
+		now := time.Now()
+		score := 100.0
+		e.FinishedAt, e.ScorePercent = &now, &score
+	}
+	if err := createExam(f.db, &e, []string{"q1"}); err != nil {
+		t.Fatal(err)
+	}
+	if finished {
+		f.db.Model(&e).Update("status", ExamStatusFinished)
+		f.db.Create(&Answer{ExamID: id, QuestionID: "q1", SelectedRaw: `["a"]`, IsCorrect: true, AnsweredAt: time.Now()})
+	}
+	return e
+}
+
+func (f *accessFixture) do(method, path, body string, u *User) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if u != nil {
+		req.AddCookie(&http.Cookie{Name: cookieName, Value: u.PublicID})
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestExamAccessPerEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string // %s = ID egzaminu
+		body     string
+		delivery string
+		finished bool
+		read     bool // trener i admin mają dostęp
+		ownerOK  int  // oczekiwany status dla właściciela
+	}{
+		{name: "view", method: "POST", path: "/api/v1/exams/%s/questions/q1/view", ownerOK: http.StatusOK},
+		{name: "next", method: "GET", path: "/api/v1/exams/%s/next", delivery: DeliverySequential, ownerOK: http.StatusOK},
+		{name: "question", method: "GET", path: "/api/v1/exams/%s/questions/q1", delivery: DeliverySequential, ownerOK: http.StatusOK},
+		{name: "answer", method: "POST", path: "/api/v1/exams/%s/answer?questionId=q1", body: `{"selected":["a"]}`, ownerOK: http.StatusOK},
+		{name: "finish", method: "POST", path: "/api/v1/exams/%s/finish", ownerOK: http.StatusBadRequest}, // bez odpowiedzi
+		{name: "pause", method: "POST", path: "/api/v1/exams/%s/pause", ownerOK: http.StatusOK},
+		{name: "resume", method: "POST", path: "/api/v1/exams/%s/resume", ownerOK: http.StatusConflict}, // nie było pauzy
+		{name: "abandon", method: "POST", path: "/api/v1/exams/%s/abandon", ownerOK: http.StatusOK},
+		{name: "delete", method: "DELETE", path: "/api/v1/exams/%s", ownerOK: http.StatusOK},
+		{name: "retake", method: "POST", path: "/api/v1/exams/%s/retake", finished: true, ownerOK: http.StatusOK},
+		{name: "detail", method: "GET", path: "/api/v1/exams/%s", finished: true, read: true, ownerOK: http.StatusOK},
+		{name: "compare", method: "GET", path: "/api/v1/exams/%s/compare/e2", finished: true, read: true, ownerOK: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccessFixture(t)
+			f.exam(t, "e1", tt.delivery, tt.finished)
+			f.exam(t, "e2", tt.delivery, tt.finished)
+			path := fmt.Sprintf(tt.path, "e1")
+
+			guestStatus := http.StatusForbidden
+			if tt.read {
+				guestStatus = http.StatusOK
+			}
+			cases := []struct {
+				who  string
+				user *User
+				want int
+			}{
+				{"anonymous", nil, http.StatusUnauthorized},
+				{"other user", f.other, http.StatusForbidden},
+				{"trainer", f.trainer, guestStatus},
+				{"admin", f.admin, guestStatus},
+				{"owner", f.owner, tt.ownerOK}, // na końcu: może zmienić egzamin
+			}
+			for _, cs := range cases {
+				if w := f.do(tt.method, path, tt.body, cs.user); w.Code != cs.want {
+					t.Errorf("%s: status %d, want %d (%s)", cs.who, w.Code, cs.want, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestExamAccessDeniedLeavesExamUntouched(t *testing.T) {
+	f := newAccessFixture(t)
+	f.exam(t, "e1", "", false)
+
+	f.do("POST", "/api/v1/exams/e1/answer?questionId=q1", `{"selected":["a"]}`, f.other)
+	w := f.do("POST", "/api/v1/exams/e1/finish", "", f.trainer)
+	if strings.Contains(w.Body.String(), "correct") {
+		t.Errorf("finish by trainer leaked the review: %s", w.Body.String())
+	}
+	var answers int64
+	f.db.Model(&Answer{}).Where("exam_id = ?", "e1").Count(&answers)
+	var e Exam
+	f.db.First(&e, "id = ?", "e1")
+	if answers != 0 || e.FinishedAt != nil {
+		t.Errorf("foreign requests changed the exam: %d answers, finishedAt %v", answers, e.FinishedAt)
+	}
+}
+
+func TestReadingOverdueExamExpiresItOnlyForOwner(t *testing.T) {
+	f := newAccessFixture(t)
+	f.exam(t, "e1", "", false)
+	f.db.Model(&Exam{}).Where("id = ?", "e1").Update("started_at", time.Now().Add(-2*time.Hour))
+
+	for _, u := range []*User{f.trainer, f.admin} {
+		w := f.do("GET", "/api/v1/exams/e1", "", u)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"expired"`) {
+			t.Errorf("%s: %d %s", u.PublicID, w.Code, w.Body.String())
+		}
+		var e Exam
+		f.db.First(&e, "id = ?", "e1")
+		if e.Status != ExamStatusInProgress {
+			t.Errorf("%s's read stored status %q", u.PublicID, e.Status)
+		}
+	}
+
+	f.do("GET", "/api/v1/exams/e1", "", f.owner)
+	var e Exam
+	f.db.First(&e, "id = ?", "e1")
+	if e.Status != ExamStatusExpired {
+		t.Errorf("owner's read: status %q, want expired", e.Status)
+	}
+}
+
+func TestIsTrainerOf(t *testing.T) {
+	f := newAccessFixture(t)
+	if ok, _ := isTrainerOf(f.db, f.trainer.ID, f.owner.ID); !ok {
+		t.Error("trainer of the owner's group not recognised")
+	}
+	if ok, _ := isTrainerOf(f.db, f.owner.ID, f.trainer.ID); ok {
+		t.Error("plain member treated as trainer")
+	}
+	if ok, _ := isTrainerOf(f.db, f.trainer.ID, f.other.ID); ok {
+		t.Error("trainer has access to a user outside the group")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Stan egzaminu (Exam.Status). Przejścia: in_progress → finished | expired | abandoned,
+// expired → finished (wynik na termin) | abandoned. Porzucone egzaminy nie liczą się do statystyk.
+const (
+	ExamStatusInProgress = "in_progress"
+	ExamStatusFinished   = "finished"
+	ExamStatusExpired    = "expired" // czas minął bez finish
+	ExamStatusAbandoned  = "abandoned"
+)
+
+var examStatuses = []string{ExamStatusInProgress, ExamStatusFinished, ExamStatusExpired, ExamStatusAbandoned}
+
+// errAssignedExam: egzaminu z zadania nie można porzucić ani usunąć — jedno podejście na usera.
+var errAssignedExam = errors.New("assigned exams can't be abandoned or deleted")
+
+// backfillExamStatus ustawia status egzaminom sprzed Exam.Status (kolumna dostaje in_progress).
+func backfillExamStatus(db *gorm.DB) error {
+	return db.Model(&Exam{}).
+		Where("finished_at IS NOT NULL AND status = ?", ExamStatusInProgress).
+		Update("status", ExamStatusFinished).Error
+}
+
+// examClosedError zwraca komunikat 409 dla egzaminu, który już nie trwa; "" = trwa.
+func examClosedError(e Exam) string {
+	switch {
+	case e.Status == ExamStatusAbandoned:
+		return "exam was abandoned"
+	case e.FinishedAt != nil:
+		return "exam already finished"
+	case e.Status == ExamStatusExpired:
+		return "exam time is up"
+	}
+	return ""
+}
+
+// rejectIfClosed odpowiada 409, gdy egzamin nie przyjmuje już zmian (examClosedError albo
+// skończył się czas — wtedy od razu dostaje status expired); false = przerwij handler.
+func rejectIfClosed(c *gin.Context, db *gorm.DB, exam *Exam) bool {
+	if msg := examClosedError(*exam); msg != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
+		return false
+	}
+	clock, err := examClock(db, exam)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return false
+	}
+	if clock.RemainingSec > 0 {
+		return true
+	}
+	if err := db.Model(exam).Update("status", ExamStatusExpired).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return false
+	}
+	exam.Status = ExamStatusExpired
+	c.JSON(http.StatusConflict, gin.H{"error": examClosedError(*exam)})
+	return false
+}
+
+// finishTime zwraca chwilę zakończenia egzaminu: now, a gdy czas już minął — termin
+// (start + czas + wliczone pauzy), więc wynik egzaminu po czasie liczy się na termin.
+func finishTime(db *gorm.DB, exam *Exam, now time.Time) (time.Time, error) {
+	ps, err := loadPauses(db, []string{exam.ID})
+	if err != nil {
+		return now, err
+	}
+	clock := computeClock(*exam, ps[exam.ID], now)
+	if clock.RemainingSec > 0 {
+		return now, nil
+	}
+	deadline := exam.StartedAt.Add(time.Duration(exam.DurationSeconds+clock.PausedSec) * time.Second)
+	if deadline.After(now) {
+		return now, nil
+	}
+	return deadline, nil
+}
+
+// expireExams oznacza jako expired trwające egzaminy usera, którym skończył się czas (z pauzami).
+func expireExams(db *gorm.DB, uid uint, now time.Time) error {
+	var exams []Exam
+	if err := db.Where("user_id = ? AND status = ?", uid, ExamStatusInProgress).Find(&exams).Error; err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(exams))
+	for _, e := range exams {
+		ids = append(ids, e.ID)
+	}
+	pauses, err := loadPauses(db, ids)
+	if err != nil {
+		return err
+	}
+	var expired []string
+	for _, e := range exams {
+		if computeClock(e, pauses[e.ID], now).RemainingSec <= 0 {
+			expired = append(expired, e.ID)
+		}
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	return db.Model(&Exam{}).Where("id IN ?", expired).Update("status", ExamStatusExpired).Error
+}
+
+// parseStatusFilter czyta ?status=in_progress,expired; nil = wszystkie.
+func parseStatusFilter(c *gin.Context) ([]string, bool) {
+	v := c.Query("status")
+	if v == "" {
+		return nil, true
+	}
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		s = strings.TrimSpace(s)
+		if !containsString(examStatuses, s) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of " + strings.Join(examStatuses, ", ")})
+			return nil, false
+		}
+		out = append(out, s)
+	}
+	return out, true
+}
+
+// POST /api/v1/exams/:id/abandon — porzuca nieukończony egzamin (zostaje w historii)
+func AbandonExam(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exam, ok := loadOwnExam(c, db)
+		if !ok {
+			return
+		}
+		if msg := examClosedError(*exam); msg != "" && exam.Status != ExamStatusExpired {
+			c.JSON(http.StatusConflict, gin.H{"error": msg})
+			return
+		}
+		if exam.AssignmentID != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": errAssignedExam.Error()})
+			return
+		}
+		now := time.Now()
+		p, err := openPause(db, exam.ID)
+		if err == nil && p != nil {
+			err = closePause(db, p, now)
+		}
+		if err == nil {
+			err = db.Model(exam).Update("status", ExamStatusAbandoned).Error
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"examId": exam.ID, "status": ExamStatusAbandoned})
+	}
+}
+
+// DELETE /api/v1/exams/:id — usuwa nieukończony egzamin razem z odpowiedziami
+func DeleteExam(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exam, ok := loadOwnExam(c, db)
+		if !ok {
+			return
+		}
+		if exam.FinishedAt != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "finished exams can't be deleted"})
+			return
+		}
+		if exam.AssignmentID != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": errAssignedExam.Error()})
+			return
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, m := range []interface{}{&Answer{}, &ExamQuestion{}, &ExamPause{}} {
+				if err := tx.Where("exam_id = ?", exam.ID).Delete(m).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Delete(exam).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestExpireExams(t *testing.T) {
+	db := newTestDB(t)
+	uid := uint(1)
+	now := time.Now()
+	paused := now.Add(-50 * time.Minute)
+	db.Create(&Exam{ID: "over", Type: "exam", UserID: &uid, StartedAt: now.Add(-2 * time.Hour), DurationSeconds: 3600, Status: ExamStatusInProgress})
+	db.Create(&Exam{ID: "running", Type: "exam", UserID: &uid, StartedAt: now.Add(-10 * time.Minute), DurationSeconds: 3600, Status: ExamStatusInProgress})
+	// bez pauzy czas by minął, ale trwająca pauza zatrzymała zegar
+	db.Create(&Exam{ID: "paused", Type: "exam", UserID: &uid, StartedAt: now.Add(-70 * time.Minute), DurationSeconds: 3600, MaxPauseSeconds: 3600, Status: ExamStatusInProgress})
+	db.Create(&ExamPause{ExamID: "paused", PausedAt: paused})
+	db.Create(&Exam{ID: "abandoned", Type: "exam", UserID: &uid, StartedAt: now.Add(-2 * time.Hour), DurationSeconds: 3600, Status: ExamStatusAbandoned})
+
+	if err := expireExams(db, uid, now); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"over":      ExamStatusExpired,
+		"running":   ExamStatusInProgress,
+		"paused":    ExamStatusInProgress,
+		"abandoned": ExamStatusAbandoned,
+	}
+	for id, status := range want {
+		var e Exam
+		db.First(&e, "id = ?", id)
+		if e.Status != status {
+			t.Errorf("%s: status %q, want %q", id, e.Status, status)
+		}
+	}
+}
+
+func TestBackfillExamStatus(t *testing.T) {
+	db := newTestDB(t)
+	finished := time.Now()
+	db.Create(&Exam{ID: "old", Type: "exam", StartedAt: finished.Add(-time.Hour), FinishedAt: &finished, DurationSeconds: 3600})
+	db.Create(&Exam{ID: "open", Type: "exam", StartedAt: finished, DurationSeconds: 3600})
+
+	if err := backfillExamStatus(db); err != nil {
+		t.Fatal(err)
+	}
+	var old, open Exam
+	db.First(&old, "id = ?", "old")
+	db.First(&open, "id = ?", "open")
+	if old.Status != ExamStatusFinished || open.Status != ExamStatusInProgress {
+		t.Errorf("statuses after backfill: old %q, open %q", old.Status, open.Status)
+	}
+}
+
+func TestStatsSkipAbandonedExams(t *testing.T) {
+	db := newTestDB(t)
+	uid := uint(1)
+	now := time.Now()
+	score := 80.0
+	db.Create(&Question{ID: "q1", TextEN: "q1"})
+	db.Create(&Exam{ID: "done", Type: "exam", UserID: &uid, StartedAt: now, FinishedAt: &now, DurationSeconds: 3600, ScorePercent: &score, Status: ExamStatusFinished})
+	db.Create(&Exam{ID: "mistake", Type: "exam", UserID: &uid, StartedAt: now, DurationSeconds: 3600, Status: ExamStatusAbandoned})
+	db.Create(&Answer{ExamID: "done", QuestionID: "q1", SelectedRaw: `["a"]`, IsCorrect: true, AnsweredAt: now})
+	db.Create(&Answer{ExamID: "mistake", QuestionID: "q1", SelectedRaw: `["b"]`, AnsweredAt: now})
+
+	st, err := computeStats(db, uid, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.TotalExams != 1 || st.CompletedExams != 1 || st.AbandonedExams != 1 {
+		t.Errorf("exam counts: total %d, completed %d, abandoned %d", st.TotalExams, st.CompletedExams, st.AbandonedExams)
+	}
+	if st.TotalAnswers != 1 || st.CorrectAnswers != 1 {
+		t.Errorf("answers: total %d, correct %d", st.TotalAnswers, st.CorrectAnswers)
+	}
+}
+
+func TestTimedOutExamRejectsAnswersAndFinishesAtDeadline(t *testing.T) {
+	f := newAccessFixture(t)
+	f.exam(t, "late", "", false)
+	f.exam(t, "expired", "", false)
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	f.db.Model(&Exam{}).Where("id = ?", "late").Update("started_at", start) // status jeszcze in_progress
+	f.db.Create(&Answer{ExamID: "late", QuestionID: "q1", SelectedRaw: `["a"]`, IsCorrect: true, AnsweredAt: start.Add(10 * time.Minute)})
+	f.db.Model(&Exam{}).Where("id = ?", "expired").Update("status", ExamStatusExpired)
+
+	for _, id := range []string{"late", "expired"} {
+		if w := f.do("POST", "/api/v1/exams/"+id+"/answer?questionId=q1", `{"selected":["b"]}`, f.owner); w.Code != http.StatusConflict {
+			t.Errorf("%s: answer status %d, want 409 (%s)", id, w.Code, w.Body.String())
+		}
+		var e Exam
+		f.db.First(&e, "id = ?", id)
+		if e.Status != ExamStatusExpired {
+			t.Errorf("%s: status %q, want expired", id, e.Status)
+		}
+	}
+
+	// odpowiedź sprzed terminu daje wynik; koniec egzaminu = termin, nie chwila finish
+	if w := f.do("POST", "/api/v1/exams/late/finish", "", f.owner); w.Code != http.StatusOK {
+		t.Fatalf("finish after the deadline: %d %s", w.Code, w.Body.String())
+	}
+	var e Exam
+	f.db.First(&e, "id = ?", "late")
+	if e.Status != ExamStatusFinished || e.ScorePercent == nil || *e.ScorePercent != 100 {
+		t.Errorf("late exam: status %q, score %v", e.Status, e.ScorePercent)
+	}
+	if e.FinishedAt == nil || !e.FinishedAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("finishedAt %v, want the deadline %v", e.FinishedAt, start.Add(time.Hour))
+	}
+
+	// bez odpowiedzi nie ma czego ocenić: egzamin zostaje expired i można go porzucić
+	if w := f.do("POST", "/api/v1/exams/expired/finish", "", f.owner); w.Code != http.StatusBadRequest {
+		t.Errorf("finish without answers: status %d (%s)", w.Code, w.Body.String())
+	}
+	if w := f.do("POST", "/api/v1/exams/expired/abandon", "", f.owner); w.Code != http.StatusOK {
+		t.Errorf("abandon expired: status %d (%s)", w.Code, w.Body.String())
+	}
+	if w := f.do("POST", "/api/v1/exams/expired/finish", "", f.owner); w.Code != http.StatusConflict {
+		t.Errorf("finish abandoned: status %d, want 409 (%s)", w.Code, w.Body.String())
+	}
+}
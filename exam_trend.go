@@ -0,0 +1,329 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	trendDefaultWindow = 3 // średnia krocząca z ostatnich 3 egzaminów
+	trendMaxWindow     = 20
+)
+
+type TagScore struct {
+	Answered     int64    `json:"answered"`
+	Correct      int64    `json:"correct"`
+	ScorePercent *float64 `json:"scorePercent,omitempty"`
+}
+
+type TrendPoint struct {
+	ExamID          string              `json:"examId"`
+	ParentExamID    *string             `json:"parentExamId,omitempty"` // powtórka tego egzaminu
+	RetakeMode      string              `json:"retakeMode,omitempty"`
+	Bank            string              `json:"bank,omitempty"`
+	StartedAt       time.Time           `json:"startedAt"`
+	FinishedAt      time.Time           `json:"finishedAt"`
+	ScorePercent    float64             `json:"scorePercent"`
+	Passed          *bool               `json:"passed"`
+	DurationSec     int                 `json:"durationSec"`
+	DurationUsedSec int                 `json:"durationUsedSec"`
+	MovingAverage   float64             `json:"movingAverage"` // średnia z ostatnich `window` egzaminów (włącznie z tym)
+	Tags            map[string]TagScore `json:"tags"`
+}
+
+type TrendExtreme struct {
+	ExamID       string  `json:"examId"`
+	ScorePercent float64 `json:"scorePercent"`
+}
+
+type ExamTrendResponse struct {
+	Window       int           `json:"window"`
+	Exams        []TrendPoint  `json:"exams"` // od najstarszego
+	AverageScore *float64      `json:"averageScore,omitempty"`
+	Best         *TrendExtreme `json:"best,omitempty"`
+	Worst        *TrendExtreme `json:"worst,omitempty"`
+}
+
+// buildExamTrend składa serię z ukończonych egzaminów (posortowanych od najstarszego)
+// i ich wyników per tag.
+func buildExamTrend(exams []Exam, tags map[string]map[string]*answerCount, window int) ExamTrendResponse {
+	out := ExamTrendResponse{Window: window, Exams: []TrendPoint{}}
+	var sum float64
+	for _, e := range exams {
+		if e.ScorePercent == nil || e.FinishedAt == nil {
+			continue
+		}
+		score := *e.ScorePercent
+		used := int(e.FinishedAt.Sub(e.StartedAt).Seconds())
+		if used < 0 {
+			used = 0
+		}
+		if e.DurationSeconds > 0 && used > e.DurationSeconds {
+			used = e.DurationSeconds
+		}
+
+		var wsum float64
+		from := maxInt(0, len(out.Exams)+1-window)
+		for _, p := range out.Exams[from:] {
+			wsum += p.ScorePercent
+		}
+		wsum += score
+
+		p := TrendPoint{
+			ExamID:          e.ID,
+			ParentExamID:    e.ParentExamID,
+			RetakeMode:      e.RetakeMode,
+			Bank:            e.BankID,
+			StartedAt:       e.StartedAt,
+			FinishedAt:      *e.FinishedAt,
+			ScorePercent:    score,
+			Passed:          examPassed(e),
+			DurationSec:     e.DurationSeconds,
+			DurationUsedSec: used,
+			MovingAverage:   wsum / float64(len(out.Exams)+1-from),
+			Tags:            map[string]TagScore{},
+		}
+		for tag, tc := range tags[e.ID] {
+			p.Tags[tag] = TagScore{Answered: tc.Total, Correct: tc.Correct, ScorePercent: tc.Accuracy()}
+		}
+		out.Exams = append(out.Exams, p)
+
+		sum += score
+		if out.Best == nil || score > out.Best.ScorePercent {
+			out.Best = &TrendExtreme{ExamID: e.ID, ScorePercent: score}
+		}
+		if out.Worst == nil || score < out.Worst.ScorePercent {
+			out.Worst = &TrendExtreme{ExamID: e.ID, ScorePercent: score}
+		}
+	}
+	if n := len(out.Exams); n > 0 {
+		avg := sum / float64(n)
+		out.AverageScore = &avg
+	}
+	return out
+}
+
+// examTagScores liczy odpowiedzi per egzamin i tag (na udzielonych odpowiedziach).
+func examTagScores(db *gorm.DB, examIDs []string) (map[string]map[string]*answerCount, error) {
+	out := map[string]map[string]*answerCount{}
+	if len(examIDs) == 0 {
+		return out, nil
+	}
+	type row struct {
+		ExamID    string
+		IsCorrect bool
+		Tags      *string
+	}
+	var rows []row
+	if err := db.Table("answers a").
+		Select("a.exam_id as exam_id, a.is_correct as is_correct, q.tags as tags").
+		Joins("JOIN questions q ON q.id = a.question_id").
+		Where("a.exam_id IN ?", examIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		for _, tag := range splitTags(r.Tags) {
+			if out[r.ExamID] == nil {
+				out[r.ExamID] = map[string]*answerCount{}
+			}
+			tc := out[r.ExamID][tag]
+			if tc == nil {
+				tc = &answerCount{}
+				out[r.ExamID][tag] = tc
+			}
+			tc.Total++
+			if r.IsCorrect {
+				tc.Correct++
+			}
+		}
+	}
+	return out, nil
+}
+
+// GET /api/v1/stats/exams/trend?bank=&window=3&exam=
+// exam= zawęża serię do egzaminu źródłowego i jego powtórek.
+func ExamScoreTrend(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		window := trendDefaultWindow
+		if v := c.Query("window"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > trendMaxWindow {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "window must be 1..20"})
+				return
+			}
+			window = n
+		}
+
+		tx := db.Where("user_id = ? AND type = ? AND finished_at IS NOT NULL AND score_percent IS NOT NULL", uid, "exam").
+			Order("finished_at")
+		if bank := c.Query("bank"); bank != "" {
+			tx = tx.Where("bank_id = ?", bank)
+		}
+		if id := c.Query("exam"); id != "" {
+			var e Exam
+			if err := db.First(&e, "id = ? AND user_id = ?", id, uid).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "exam not found"})
+				return
+			}
+			family, err := retakeFamily(db, e)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			tx = tx.Where("id IN ?", family)
+		}
+		var exams []Exam
+		if err := tx.Find(&exams).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		ids := make([]string, 0, len(exams))
+		for _, e := range exams {
+			ids = append(ids, e.ID)
+		}
+		tags, err := examTagScores(db, ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, buildExamTrend(exams, tags, window))
+	}
+}
+
+// ===== Porównanie dwóch podejść do tego samego zestawu (ten sam Seed albo powtórka) =====
+
+const (
+	CompareImproved  = "improved"  // źle → dobrze
+	CompareRegressed = "regressed" // dobrze → źle
+	CompareSame      = "same"      // wynik bez zmian
+	CompareOnlyLeft  = "only_left" // pytanie tylko w pierwszym egzaminie
+	CompareOnlyRight = "only_right"
+)
+
+type CompareSide struct {
+	Answered   bool     `json:"answered"`
+	Selected   []string `json:"selected"`
+	WasCorrect bool     `json:"wasCorrect"`
+}
+
+type CompareRow struct {
+	QuestionID   string       `json:"questionId"`
+	QuestionText string       `json:"questionText"`
+	Correct      []string     `json:"correct"`
+	Left         *CompareSide `json:"left,omitempty"`
+	Right        *CompareSide `json:"right,omitempty"`
+	Change       string       `json:"change"`
+}
+
+type CompareSummary struct {
+	Improved   int      `json:"improved"`
+	Regressed  int      `json:"regressed"`
+	Same       int      `json:"same"`
+	ScoreDelta *float64 `json:"scoreDelta,omitempty"` // right - left, gdy oba ukończone
+}
+
+func compareSide(r ReviewRow) *CompareSide {
+	return &CompareSide{Answered: len(r.Selected) > 0, Selected: r.Selected, WasCorrect: r.WasCorrect}
+}
+
+// compareReviews zestawia dwa review pytanie po pytaniu, w kolejności pierwszego egzaminu.
+func compareReviews(left, right []ReviewRow) ([]CompareRow, CompareSummary) {
+	var sum CompareSummary
+	rightByID := make(map[string]ReviewRow, len(right))
+	for _, r := range right {
+		rightByID[r.QuestionID] = r
+	}
+	rows := []CompareRow{}
+	seen := map[string]bool{}
+	for _, l := range left {
+		seen[l.QuestionID] = true
+		row := CompareRow{QuestionID: l.QuestionID, QuestionText: l.QuestionText, Correct: l.Correct, Left: compareSide(l)}
+		r, ok := rightByID[l.QuestionID]
+		switch {
+		case !ok:
+			row.Change = CompareOnlyLeft
+		case !l.WasCorrect && r.WasCorrect:
+			row.Right, row.Change = compareSide(r), CompareImproved
+			sum.Improved++
+		case l.WasCorrect && !r.WasCorrect:
+			row.Right, row.Change = compareSide(r), CompareRegressed
+			sum.Regressed++
+		default:
+			row.Right, row.Change = compareSide(r), CompareSame
+			sum.Same++
+		}
+		rows = append(rows, row)
+	}
+	for _, r := range right {
+		if !seen[r.QuestionID] {
+			rows = append(rows, CompareRow{QuestionID: r.QuestionID, QuestionText: r.QuestionText, Correct: r.Correct, Right: compareSide(r), Change: CompareOnlyRight})
+		}
+	}
+	return rows, sum
+}
+
+// GET /api/v1/exams/:id/compare/:otherId
+func CompareExams(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var exams [2]Exam
+		for i, id := range []string{c.Param("id"), c.Param("otherId")} {
+			e, ok := loadExam(c, db, id, examAccessRead)
+			if !ok {
+				return
+			}
+			exams[i] = *e
+		}
+		left, right := exams[0], exams[1]
+		if left.UserID == nil || right.UserID == nil || *left.UserID != *right.UserID {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "exams must belong to the same user"})
+			return
+		}
+		sameSeed := left.Seed != nil && right.Seed != nil && *left.Seed == *right.Seed
+		if !sameSeed {
+			related, err := sameRetakeFamily(db, left, right)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			sameSeed = related
+		}
+		if !sameSeed || left.BankID != right.BankID {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "exams must share the same seed and bank, or be retakes of one another"})
+			return
+		}
+
+		leftReview, _, err := buildReview(db, left.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		rightReview, _, err := buildReview(db, right.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		rows, sum := compareReviews(leftReview, rightReview)
+		if left.ScorePercent != nil && right.ScorePercent != nil {
+			d := *right.ScorePercent - *left.ScorePercent
+			sum.ScoreDelta = &d
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"seed":    left.Seed,
+			"left":    gin.H{"examId": left.ID, "startedAt": left.StartedAt, "scorePercent": left.ScorePercent, "passed": examPassed(left)},
+			"right":   gin.H{"examId": right.ID, "startedAt": right.StartedAt, "scorePercent": right.ScorePercent, "passed": examPassed(right)},
+			"summary": sum,
+			"items":   rows,
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildExamTrend(t *testing.T) {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	exam := func(id string, score float64, usedMin int) Exam {
+		fin := start.Add(time.Duration(usedMin) * time.Minute)
+		return Exam{ID: id, Type: "exam", StartedAt: start, FinishedAt: &fin, DurationSeconds: 3600, ScorePercent: &score}
+	}
+	exams := []Exam{exam("e1", 40, 30), exam("e2", 70, 90), exam("e3", 55, 45), {ID: "open", Type: "exam", StartedAt: start}}
+	tags := map[string]map[string]*answerCount{"e1": {"OCC": {Total: 4, Correct: 1}}}
+
+	tr := buildExamTrend(exams, tags, 2)
+	if len(tr.Exams) != 3 {
+		t.Fatalf("got %d points, want 3 finished exams", len(tr.Exams))
+	}
+	want := []float64{40, 55, 62.5} // okno 2
+	for i, p := range tr.Exams {
+		if p.MovingAverage != want[i] {
+			t.Errorf("exam %d moving average = %v, want %v", i, p.MovingAverage, want[i])
+		}
+	}
+	if tr.Exams[1].DurationUsedSec != 3600 || tr.Exams[0].DurationUsedSec != 1800 {
+		t.Errorf("duration used not capped: %+v", tr.Exams)
+	}
+	if occ := tr.Exams[0].Tags["OCC"]; occ.ScorePercent == nil || *occ.ScorePercent != 25 {
+		t.Errorf("per-tag score = %+v", occ)
+	}
+	if tr.Best.ExamID != "e2" || tr.Worst.ExamID != "e1" || *tr.AverageScore != 55 {
+		t.Errorf("best=%+v worst=%+v avg=%v", tr.Best, tr.Worst, *tr.AverageScore)
+	}
+	if *tr.Exams[0].Passed || !*tr.Exams[1].Passed {
+		t.Error("passed flag should follow the 61% threshold")
+	}
+}
+
+func TestCompareReviews(t *testing.T) {
+	left := []ReviewRow{
+		{QuestionID: "q1", WasCorrect: false, Selected: []string{"a"}},
+		{QuestionID: "q2", WasCorrect: true, Selected: []string{"b"}},
+		{QuestionID: "q3", WasCorrect: true, Selected: []string{"c"}},
+		{QuestionID: "q4"},
+	}
+	right := []ReviewRow{
+		{QuestionID: "q2", WasCorrect: false},
+		{QuestionID: "q1", WasCorrect: true, Selected: []string{"b"}},
+		{QuestionID: "q3", WasCorrect: true, Selected: []string{"c"}},
+		{QuestionID: "q5"},
+	}
+	rows, sum := compareReviews(left, right)
+	changes := []string{CompareImproved, CompareRegressed, CompareSame, CompareOnlyLeft, CompareOnlyRight}
+	if len(rows) != len(changes) {
+		t.Fatalf("rows = %+v", rows)
+	}
+	for i, want := range changes {
+		if rows[i].Change != want {
+			t.Errorf("row %d (%s) change = %s, want %s", i, rows[i].QuestionID, rows[i].Change, want)
+		}
+	}
+	if sum.Improved != 1 || sum.Regressed != 1 || sum.Same != 1 {
+		t.Errorf("summary = %+v", sum)
+	}
+	if rows[1].Right.Answered {
+		t.Error("unanswered question on the right reported as answered")
+	}
+}
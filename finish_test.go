@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type finishResp struct {
+	AlreadyFinished bool       `json:"alreadyFinished"`
+	FinishedAt      *time.Time `json:"finishedAt"`
+	ScorePercent    *float64   `json:"scorePercent"`
+	Correct         int        `json:"correct"`
+}
+
+func TestFinishExamIsIdempotent(t *testing.T) {
+	f := newAccessFixture(t)
+	f.exam(t, "e1", "", false)
+	if w := f.do("POST", "/api/v1/exams/e1/answer?questionId=q1", `{"selected":["a"]}`, f.owner); w.Code != http.StatusOK {
+		t.Fatalf("answer: %d %s", w.Code, w.Body.String())
+	}
+
+	var first, second finishResp
+	for i, out := range []*finishResp{&first, &second} {
+		w := f.do("POST", "/api/v1/exams/e1/finish", "", f.owner)
+		if w.Code != http.StatusOK {
+			t.Fatalf("finish #%d: %d %s", i+1, w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first.AlreadyFinished || !second.AlreadyFinished {
+		t.Errorf("alreadyFinished: first %v, second %v", first.AlreadyFinished, second.AlreadyFinished)
+	}
+	if first.ScorePercent == nil || second.ScorePercent == nil || *first.ScorePercent != *second.ScorePercent || first.Correct != second.Correct {
+		t.Errorf("second finish changed the result: %+v vs %+v", first, second)
+	}
+	if first.FinishedAt == nil || second.FinishedAt == nil || !first.FinishedAt.Equal(*second.FinishedAt) {
+		t.Errorf("finishedAt moved: %v vs %v", first.FinishedAt, second.FinishedAt)
+	}
+}
+
+func TestAnswerAfterFinishRejected(t *testing.T) {
+	f := newAccessFixture(t)
+	f.exam(t, "e1", "", true) // zapisana poprawna odpowiedź "a"
+
+	w := f.do("POST", "/api/v1/exams/e1/answer?questionId=q1", `{"selected":["b"]}`, f.owner)
+	if w.Code != http.StatusConflict {
+		t.Errorf("answer after finish: status %d, want 409 (%s)", w.Code, w.Body.String())
+	}
+	var a Answer
+	f.db.First(&a, "exam_id = ? AND question_id = ?", "e1", "q1")
+	if a.SelectedRaw != `["a"]` || !a.IsCorrect {
+		t.Errorf("stored answer changed: %+v", a)
+	}
+
+	ans := Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: `["b"]`, AnsweredAt: time.Now()}
+	if err := saveExamAnswer(f.db, &ans); !errors.Is(err, errAlreadyFinished) {
+		t.Errorf("saveExamAnswer on finished exam: %v", err)
+	}
+}
+
+func TestFinishExamConcurrent(t *testing.T) {
+	f := newAccessFixture(t)
+	f.exam(t, "e1", "", false)
+	f.db.Create(&Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: `["a"]`, IsCorrect: true, AnsweredAt: time.Now()})
+
+	const n = 5
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = finishExam(f.db, "e1", time.Now().Add(time.Duration(i)*time.Minute))
+		}(i)
+	}
+	wg.Wait()
+	finished := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			finished++
+		case !errors.Is(err, errAlreadyFinished):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if finished != 1 {
+		t.Errorf("%d calls finished the exam, want 1", finished)
+	}
+	if err := finishExam(f.db, "e1", time.Now()); !errors.Is(err, errAlreadyFinished) {
+		t.Errorf("finish of a finished exam: %v", err)
+	}
+}
+
+func TestRepeatedAnswersScoreOnce(t *testing.T) {
+	f := newAccessFixture(t)
+	f.db.Create(&Question{ID: "q2", TextEN: "q2"})
+	f.db.Create(&Option{QuestionID: "q2", OptionKey: "a", TextEN: "a", IsCorrect: true})
+	seed := int64(1)
+	e := Exam{ID: "e1", Type: "exam", UserID: &f.owner.ID, StartedAt: time.Now(), DurationSeconds: 3600, Seed: &seed}
+	if err := createExam(f.db, &e, []string{"q1", "q2"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if w := f.do("POST", "/api/v1/exams/e1/answer?questionId=q1", `{"selected":["a"]}`, f.owner); w.Code != http.StatusOK {
+			t.Fatalf("answer #%d: %d %s", i+1, w.Code, w.Body.String())
+		}
+	}
+	w := f.do("POST", "/api/v1/exams/e1/finish", "", f.owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("finish: %d %s", w.Code, w.Body.String())
+	}
+	var out finishResp
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	// q2 pominięte: 1 z 2 pytań, niezależnie od liczby powtórzeń q1
+	if out.ScorePercent == nil || *out.ScorePercent != 50 || out.Correct != 1 {
+		t.Errorf("score %v, correct %d, want 50%% and 1", out.ScorePercent, out.Correct)
+	}
+}
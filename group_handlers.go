@@ -0,0 +1,440 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CreateGroupReq struct {
+	Name string `json:"name"`
+}
+
+type JoinGroupReq struct {
+	InviteCode string `json:"inviteCode"`
+}
+
+type SetRoleReq struct {
+	Role string `json:"role"` // "trainer" | "member"
+}
+
+type MyGroupDTO struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name"`
+	Role       string    `json:"role"`
+	InviteCode string    `json:"inviteCode,omitempty"` // tylko dla trenera
+	Members    int64     `json:"members"`
+	JoinedAt   time.Time `json:"joinedAt"`
+}
+
+type MemberProgressDTO struct {
+	UserID      uint           `json:"userId"`
+	DisplayName *string        `json:"displayName,omitempty"`
+	Role        string         `json:"role"`
+	JoinedAt    time.Time      `json:"joinedAt"`
+	Stats       *StatsResponse `json:"stats"`
+}
+
+type HeatmapCell struct {
+	Answered int64    `json:"answered"`
+	Accuracy *float64 `json:"accuracy,omitempty"`
+}
+
+type HeatmapRow struct {
+	UserID      uint                   `json:"userId"`
+	DisplayName *string                `json:"displayName,omitempty"`
+	Tags        map[string]HeatmapCell `json:"tags"`
+}
+
+func newInviteCode() string {
+	return strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:10])
+}
+
+// groupAccess ładuje grupę z :id i członkostwo bieżącego usera.
+// Przy requireTrainer odrzuca zwykłych członków. Błędy zapisuje do odpowiedzi.
+func groupAccess(c *gin.Context, db *gorm.DB, requireTrainer bool) (*Group, *GroupMember, bool) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+		return nil, nil, false
+	}
+	gid, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad group id"})
+		return nil, nil, false
+	}
+	var g Group
+	if err := db.First(&g, gid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+		return nil, nil, false
+	}
+	var m GroupMember
+	if err := db.First(&m, "group_id = ? AND user_id = ?", g.ID, uid).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a group member"})
+		return nil, nil, false
+	}
+	if requireTrainer && m.Role != GroupRoleTrainer {
+		c.JSON(http.StatusForbidden, gin.H{"error": "trainer role required"})
+		return nil, nil, false
+	}
+	return &g, &m, true
+}
+
+// POST /api/v1/groups
+func CreateGroup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		var req CreateGroupReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		name := strings.TrimSpace(req.Name)
+		if len(name) < 2 || len(name) > 80 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 2..80 chars"})
+			return
+		}
+
+		g := Group{Name: name, InviteCode: newInviteCode(), OwnerID: uid}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&g).Error; err != nil {
+				return err
+			}
+			// twórca grupy jest jej trenerem
+			return tx.Create(&GroupMember{GroupID: g.ID, UserID: uid, Role: GroupRoleTrainer, JoinedAt: time.Now()}).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusCreated, g)
+	}
+}
+
+// GET /api/v1/groups
+func ListMyGroups(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		var ms []GroupMember
+		if err := db.Where("user_id = ?", uid).Order("joined_at").Find(&ms).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		out := make([]MyGroupDTO, 0, len(ms))
+		for _, m := range ms {
+			var g Group
+			if err := db.First(&g, m.GroupID).Error; err != nil {
+				continue
+			}
+			var count int64
+			_ = db.Model(&GroupMember{}).Where("group_id = ?", g.ID).Count(&count).Error
+			dto := MyGroupDTO{ID: g.ID, Name: g.Name, Role: m.Role, Members: count, JoinedAt: m.JoinedAt}
+			if m.Role == GroupRoleTrainer {
+				dto.InviteCode = g.InviteCode
+			}
+			out = append(out, dto)
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// POST /api/v1/groups/join
+func JoinGroup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		var req JoinGroupReq
+		if err := c.BindJSON(&req); err != nil || strings.TrimSpace(req.InviteCode) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "inviteCode required"})
+			return
+		}
+		var g Group
+		if err := db.First(&g, "invite_code = ?", strings.ToUpper(strings.TrimSpace(req.InviteCode))).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+			return
+		}
+		var existing GroupMember
+		if err := db.First(&existing, "group_id = ? AND user_id = ?", g.ID, uid).Error; err == nil {
+			c.JSON(http.StatusOK, gin.H{"groupId": g.ID, "name": g.Name, "role": existing.Role})
+			return
+		}
+		m := GroupMember{GroupID: g.ID, UserID: uid, Role: GroupRoleMember, JoinedAt: time.Now()}
+		if err := db.Create(&m).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"groupId": g.ID, "name": g.Name, "role": m.Role})
+	}
+}
+
+var errLastTrainer = errors.New("last trainer")
+
+// POST /api/v1/groups/:id/leave
+// Ostatni trener nie może opuścić grupy, w której są jeszcze inni członkowie.
+// Gdy wychodzi ostatnia osoba, grupa jest usuwana.
+func LeaveGroup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		g, m, ok := groupAccess(c, db, false)
+		if !ok {
+			return
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var members, trainers int64
+			if err := tx.Model(&GroupMember{}).Where("group_id = ?", g.ID).Count(&members).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&GroupMember{}).Where("group_id = ? AND role = ?", g.ID, GroupRoleTrainer).Count(&trainers).Error; err != nil {
+				return err
+			}
+			if m.Role == GroupRoleTrainer && trainers == 1 && members > 1 {
+				return errLastTrainer
+			}
+			if err := tx.Delete(m).Error; err != nil {
+				return err
+			}
+			if members == 1 {
+				return deleteGroup(tx, g.ID)
+			}
+			return nil
+		})
+		switch {
+		case errors.Is(err, errLastTrainer):
+			c.JSON(http.StatusConflict, gin.H{"error": "promote another trainer before leaving"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "left"})
+	}
+}
+
+// deleteGroup usuwa grupę razem z członkostwami i zadaniami (z ich pytaniami);
+// egzaminy z tych zadań zostają jako zwykłe egzaminy.
+func deleteGroup(tx *gorm.DB, groupID uint) error {
+	var aids []uint
+	if err := tx.Model(&Assignment{}).Where("group_id = ?", groupID).Pluck("id", &aids).Error; err != nil {
+		return err
+	}
+	if len(aids) > 0 {
+		if err := tx.Model(&Exam{}).Where("assignment_id IN ?", aids).Update("assignment_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("assignment_id IN ?", aids).Delete(&AssignmentQuestion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", aids).Delete(&Assignment{}).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("group_id = ?", groupID).Delete(&GroupMember{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&Group{}, groupID).Error
+}
+
+// groupMember ładuje członka :userId z grupy g.
+func groupMember(c *gin.Context, db *gorm.DB, g *Group) (*GroupMember, bool) {
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad user id"})
+		return nil, false
+	}
+	var m GroupMember
+	if err := db.First(&m, "group_id = ? AND user_id = ?", g.ID, memberID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+		return nil, false
+	}
+	return &m, true
+}
+
+// PUT /api/v1/groups/:id/members/:userId/role (trener)
+func SetMemberRole(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		g, self, ok := groupAccess(c, db, true)
+		if !ok {
+			return
+		}
+		m, ok := groupMember(c, db, g)
+		if !ok {
+			return
+		}
+		var req SetRoleReq
+		if err := c.BindJSON(&req); err != nil || (req.Role != GroupRoleTrainer && req.Role != GroupRoleMember) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "role must be trainer|member"})
+			return
+		}
+		if m.ID == self.ID && req.Role != GroupRoleTrainer {
+			var trainers int64
+			_ = db.Model(&GroupMember{}).Where("group_id = ? AND role = ?", g.ID, GroupRoleTrainer).Count(&trainers).Error
+			if trainers <= 1 {
+				c.JSON(http.StatusConflict, gin.H{"error": "group needs at least one trainer"})
+				return
+			}
+		}
+		m.Role = req.Role
+		if err := db.Save(m).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"userId": m.UserID, "role": m.Role})
+	}
+}
+
+// loadGroupMembers zwraca członków grupy razem z ich profilami.
+func loadGroupMembers(db *gorm.DB, groupID uint) ([]GroupMember, map[uint]User, error) {
+	var ms []GroupMember
+	if err := db.Where("group_id = ?", groupID).Order("joined_at").Find(&ms).Error; err != nil {
+		return nil, nil, err
+	}
+	ids := make([]uint, 0, len(ms))
+	for _, m := range ms {
+		ids = append(ids, m.UserID)
+	}
+	users := map[uint]User{}
+	if len(ids) > 0 {
+		var us []User
+		if err := db.Where("id IN ?", ids).Find(&us).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, u := range us {
+			users[u.ID] = u
+		}
+	}
+	return ms, users, nil
+}
+
+// GET /api/v1/groups/:id/members?bank= (trener) — postęp każdego członka
+func GroupProgress(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		g, _, ok := groupAccess(c, db, true)
+		if !ok {
+			return
+		}
+		ms, users, err := loadGroupMembers(db, g.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		out := make([]MemberProgressDTO, 0, len(ms))
+		for _, m := range ms {
+			stats, err := computeStats(db, m.UserID, c.Query("bank"))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			out = append(out, MemberProgressDTO{
+				UserID:      m.UserID,
+				DisplayName: users[m.UserID].DisplayName,
+				Role:        m.Role,
+				JoinedAt:    m.JoinedAt,
+				Stats:       stats,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"group": g, "members": out})
+	}
+}
+
+// GET /api/v1/groups/:id/members/:userId/exams (trener) — historia egzaminów członka
+func GroupMemberExams(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		g, _, ok := groupAccess(c, db, true)
+		if !ok {
+			return
+		}
+		m, ok := groupMember(c, db, g)
+		if !ok {
+			return
+		}
+		limit, offset := parsePagination(c)
+		items, total, err := listExamSummaries(db, m.UserID, nil, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"items":  items,
+		})
+	}
+}
+
+// GET /api/v1/groups/:id/heatmap?bank= (trener) — skuteczność per członek × tag
+func GroupHeatmap(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		g, _, ok := groupAccess(c, db, true)
+		if !ok {
+			return
+		}
+		ms, users, err := loadGroupMembers(db, g.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		ids := make([]uint, 0, len(ms))
+		for _, m := range ms {
+			ids = append(ids, m.UserID)
+		}
+		byUser, err := countAnswersByTag(db, ids, c.Query("bank"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+
+		cohort := map[string]*answerCount{}
+		rows := make([]HeatmapRow, 0, len(ms))
+		for _, m := range ms {
+			row := HeatmapRow{UserID: m.UserID, DisplayName: users[m.UserID].DisplayName, Tags: map[string]HeatmapCell{}}
+			for tag, tc := range byUser[m.UserID] {
+				row.Tags[tag] = HeatmapCell{Answered: tc.Total, Accuracy: tc.Accuracy()}
+				if cohort[tag] == nil {
+					cohort[tag] = &answerCount{}
+				}
+				cohort[tag].Total += tc.Total
+				cohort[tag].Correct += tc.Correct
+			}
+			rows = append(rows, row)
+		}
+
+		tags := make([]string, 0, len(cohort))
+		totals := map[string]HeatmapCell{}
+		for tag, tc := range cohort {
+			tags = append(tags, tag)
+			totals[tag] = HeatmapCell{Answered: tc.Total, Accuracy: tc.Accuracy()}
+		}
+		// najsłabsze obszary kohorty na początku
+		sort.Slice(tags, func(i, j int) bool {
+			ai, aj := totals[tags[i]].Accuracy, totals[tags[j]].Accuracy
+			if *ai != *aj {
+				return *ai < *aj
+			}
+			return tags[i] < tags[j]
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"tags":    tags,
+			"cohort":  totals,
+			"members": rows,
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newGroupFixture: fixture egzaminów z trasami grup i grupą trenera (kod "JOINME").
+func newGroupFixture(t *testing.T) (*accessFixture, Group) {
+	t.Helper()
+	f := newAccessFixture(t)
+	api := f.router.Group("/api/v1", LoadUser(f.db))
+	api.POST("/groups/join", JoinGroup(f.db))
+	api.POST("/groups/:id/leave", LeaveGroup(f.db))
+	api.GET("/groups/:id/members", GroupProgress(f.db))
+	api.GET("/groups/:id/heatmap", GroupHeatmap(f.db))
+	api.PUT("/groups/:id/members/:userId/role", SetMemberRole(f.db))
+
+	g := Group{Name: "team", InviteCode: "JOINME", OwnerID: f.trainer.ID}
+	f.db.Create(&g)
+	f.db.Create(&GroupMember{GroupID: g.ID, UserID: f.trainer.ID, Role: GroupRoleTrainer, JoinedAt: time.Now()})
+	return f, g
+}
+
+func (f *accessFixture) isMember(g Group, u *User) bool {
+	var n int64
+	f.db.Model(&GroupMember{}).Where("group_id = ? AND user_id = ?", g.ID, u.ID).Count(&n)
+	return n > 0
+}
+
+func TestJoinGroupByInviteCode(t *testing.T) {
+	f, g := newGroupFixture(t)
+
+	if w := f.do("POST", "/api/v1/groups/join", `{"inviteCode":"nope"}`, f.other); w.Code != http.StatusNotFound {
+		t.Errorf("unknown code: status %d, want 404", w.Code)
+	}
+	w := f.do("POST", "/api/v1/groups/join", `{"inviteCode":" joinme "}`, f.other) // wielkość liter i spacje bez znaczenia
+	if w.Code != http.StatusOK {
+		t.Fatalf("join: status %d (%s)", w.Code, w.Body.String())
+	}
+	var body struct {
+		GroupID uint   `json:"groupId"`
+		Role    string `json:"role"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	if body.GroupID != g.ID || body.Role != GroupRoleMember || !f.isMember(g, f.other) {
+		t.Errorf("join result %+v, member %v", body, f.isMember(g, f.other))
+	}
+	// ponowne dołączenie nie dubluje członkostwa
+	f.do("POST", "/api/v1/groups/join", `{"inviteCode":"JOINME"}`, f.other)
+	var n int64
+	f.db.Model(&GroupMember{}).Where("group_id = ? AND user_id = ?", g.ID, f.other.ID).Count(&n)
+	if n != 1 {
+		t.Errorf("memberships after second join = %d, want 1", n)
+	}
+}
+
+func TestGroupDashboardTrainerOnly(t *testing.T) {
+	f, g := newGroupFixture(t)
+	f.db.Create(&GroupMember{GroupID: g.ID, UserID: f.other.ID, Role: GroupRoleMember, JoinedAt: time.Now()})
+
+	for _, path := range []string{"/api/v1/groups/%d/members", "/api/v1/groups/%d/heatmap"} {
+		p := fmt.Sprintf(path, g.ID)
+		if w := f.do("GET", p, "", f.other); w.Code != http.StatusForbidden {
+			t.Errorf("%s as member: status %d, want 403", p, w.Code)
+		}
+		if w := f.do("GET", p, "", f.owner); w.Code != http.StatusForbidden {
+			t.Errorf("%s as outsider: status %d, want 403", p, w.Code)
+		}
+		if w := f.do("GET", p, "", f.trainer); w.Code != http.StatusOK {
+			t.Errorf("%s as trainer: status %d (%s)", p, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestLeaveGroup(t *testing.T) {
+	f, g := newGroupFixture(t)
+	f.db.Create(&GroupMember{GroupID: g.ID, UserID: f.other.ID, Role: GroupRoleMember, JoinedAt: time.Now()})
+	leave := fmt.Sprintf("/api/v1/groups/%d/leave", g.ID)
+
+	if w := f.do("POST", leave, "", f.trainer); w.Code != http.StatusConflict {
+		t.Errorf("last trainer leaving: status %d, want 409", w.Code)
+	}
+	if !f.isMember(g, f.trainer) {
+		t.Error("last trainer was removed")
+	}
+
+	if w := f.do("POST", leave, "", f.other); w.Code != http.StatusOK {
+		t.Errorf("member leaving: status %d (%s)", w.Code, w.Body.String())
+	}
+	if f.isMember(g, f.other) {
+		t.Error("member still in the group after leaving")
+	}
+
+	// sam trener może wyjść; pusta grupa znika
+	if w := f.do("POST", leave, "", f.trainer); w.Code != http.StatusOK {
+		t.Errorf("sole trainer leaving an empty group: status %d", w.Code)
+	}
+	if err := f.db.First(&Group{}, g.ID).Error; err == nil {
+		t.Error("empty group not deleted")
+	}
+}
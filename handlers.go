@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -18,6 +19,7 @@ type QuestionDTO struct {
 	ID           string      `json:"id"`
 	QuestionText string      `json:"questionText"`
 	MultiSelect  bool        `json:"multiSelect"`
+	SelectCount  int         `json:"selectCount"` // how many options to pick (number of correct ones)
 	Options      []OptionDTO `json:"options"`
 }
 
@@ -39,20 +41,30 @@ type LearnAnswerReq struct {
 	Lang       string   `json:"lang"` // "en" | "pl"
 }
 
-const passThreshold = 61.0 // percent
+const passThreshold = 61.0 // percent; default when a bank doesn't set its own
 
-func passedPtr(score *float64) *bool {
-	if score == nil {
-		return nil // exam not finished yet
+// examPassed applies the pass mark stored on the exam at start (bank's passPercent).
+func examPassed(e Exam) *bool {
+	if e.ScorePercent == nil || e.Type == ExamTypeDrill {
+		return nil // exam not finished yet; drills have no pass mark
 	}
-	v := *score >= passThreshold
+	threshold := e.PassPercent
+	if threshold <= 0 {
+		threshold = passThreshold // exams from before banks
+	}
+	v := *e.ScorePercent >= threshold
 	return &v
 }
 
+// ListQuestions returns questions of one bank (?bank=, default bank when omitted).
 func ListQuestions(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		bank, ok := bankFromRequest(c, db, c.Query("bank"))
+		if !ok {
+			return
+		}
 		var qs []Question
-		if err := db.Preload("Options").Order("id").Find(&qs).Error; err != nil {
+		if err := db.Preload("Options").Where("bank_id = ?", bank.ID).Order("id").Find(&qs).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
@@ -63,7 +75,7 @@ func ListQuestions(db *gorm.DB) gin.HandlerFunc {
 				opts = append(opts, OptionDTO{ID: o.OptionKey, Text: o.TextEN})
 			}
 			out = append(out, QuestionDTO{
-				ID: q.ID, QuestionText: q.TextEN, MultiSelect: q.MultiSelect, Options: opts,
+				ID: q.ID, QuestionText: q.TextEN, MultiSelect: q.MultiSelect, SelectCount: selectCount(q.Options), Options: opts,
 			})
 		}
 		c.JSON(http.StatusOK, out)
@@ -88,31 +100,28 @@ func LearnAnswer(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		correct, err := computeCorrectKeys(db, req.QuestionID)
-		if err != nil {
+		var opts []Option
+		if err := db.Where("question_id = ?", req.QuestionID).Find(&opts).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
+		correct := correctKeysOf(opts)
 		if len(correct) == 0 {
 			c.JSON(http.StatusConflict, gin.H{"error": "question has no options/correct answers in DB"})
 			return
 		}
+		if serr := validateSelection(req.Selected, opts); serr != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid selection", "details": serr})
+			return
+		}
 
 		ok := isCorrectAllOrNothing(req.Selected, correct)
 
-		lang := "en"
-		if strings.ToLower(req.Lang) == "pl" {
-			lang = "pl"
-		}
-		var expl []Explanation
-		if err := db.Where("question_id = ? AND lang = ?", req.QuestionID, lang).Find(&expl).Error; err != nil {
+		byKey, err := loadExplanations(db, req.QuestionID, req.Lang)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
-		byKey := map[string]ExpDTO{}
-		for _, e := range expl {
-			byKey[e.OptionKey] = ExpDTO{Text: e.Text, URL: e.URL}
-		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"isCorrect":        ok,
@@ -125,28 +134,111 @@ func LearnAnswer(db *gorm.DB) gin.HandlerFunc {
 /*** Exam mode ***/
 
 type StartExamReq struct {
-	Count       int    `json:"count"`       // default 80
-	DurationSec int    `json:"durationSec"` // default 10800
-	Seed        *int64 `json:"seed"`        // optional for reproducibility
+	Bank         string  `json:"bank"`         // optional; default bank when empty
+	Count        int     `json:"count"`        // default: bank's defaultQuestionCount (80); max questions for adaptive
+	DurationSec  int     `json:"durationSec"`  // default: bank's defaultDurationSec (10800)
+	Seed         *int64  `json:"seed"`         // optional for reproducibility
+	AssignmentID *uint   `json:"assignmentId"` // optional: start a trainer-assigned exam
+	Scoring      string  `json:"scoring"`      // optional: all_or_nothing (default) | partial | partial_penalty | negative
+	Type         string  `json:"type"`         // "exam" (default) | "adaptive" | "drill"
+	TargetSE     float64 `json:"targetSE"`     // adaptive only: stop at this standard error (default 0.3)
+	Delivery     string  `json:"delivery"`     // "all" (default) | "sequential": one question at a time via GET /exams/:id/next
+	AllowBack    bool    `json:"allowBack"`    // sequential only: allow returning to earlier questions
+	LockAnswered bool    `json:"lockAnswered"` // sequential only: answers can't be changed
+	MaxPauseSec  *int    `json:"maxPauseSec"`  // total pause allowance in seconds (default 0: no pausing)
+	QuestionTimeSec int  `json:"questionTimeSec"` // drill only: per-question timer, 0 = none
+}
+
+// createExam stores the exam and its questions (positions follow qids order) in one transaction.
+func createExam(db *gorm.DB, exam *Exam, qids []string) error {
+	if exam.Status == "" {
+		exam.Status = ExamStatusInProgress
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(exam).Error; err != nil {
+			return err
+		}
+		for i, qid := range qids {
+			eq := ExamQuestion{ExamID: exam.ID, QuestionID: qid, Position: i + 1}
+			if err := tx.Create(&eq).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// loadQuestionDTOs fetches questions & keeps the order of ids.
+func loadQuestionDTOs(db *gorm.DB, ids []string) ([]QuestionDTO, error) {
+	var qs []Question
+	if err := db.Preload("Options").Where("id IN ?", ids).Find(&qs).Error; err != nil {
+		return nil, err
+	}
+	index := map[string]Question{}
+	for _, q := range qs {
+		index[q.ID] = q
+	}
+	out := make([]QuestionDTO, 0, len(ids))
+	for _, id := range ids {
+		q := index[id]
+		opts := make([]OptionDTO, 0, len(q.Options))
+		for _, o := range q.Options {
+			opts = append(opts, OptionDTO{ID: o.OptionKey, Text: o.TextEN})
+		}
+		out = append(out, QuestionDTO{
+			ID: q.ID, QuestionText: q.TextEN, MultiSelect: q.MultiSelect, SelectCount: selectCount(q.Options), Options: opts,
+		})
+	}
+	return out, nil
 }
 
 func StartExam(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req StartExamReq
 		_ = c.BindJSON(&req)
-		if req.Count <= 0 {
-			req.Count = 80
+		if req.AssignmentID != nil {
+			startAssignedExam(c, db, *req.AssignmentID)
+			return
 		}
-		if req.DurationSec <= 0 {
-			req.DurationSec = 3 * 60 * 60
+		if req.Type != "" && req.Type != "exam" && req.Type != ExamTypeAdaptive && req.Type != ExamTypeDrill {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "type must be exam|adaptive|drill"})
+			return
 		}
-
-		var ids []string
-		if err := db.Model(&Question{}).Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "no questions"})
+		if req.Delivery == "" {
+			req.Delivery = DeliveryAll
+		}
+		if req.Delivery != DeliveryAll && req.Delivery != DeliverySequential {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "delivery must be all|sequential"})
 			return
 		}
-		drawn := drawQuestions(ids, req.Count, req.Seed)
+		if req.Scoring == "" {
+			req.Scoring = ScoringAllOrNothing
+		}
+		if !containsString(scoringStrategies, req.Scoring) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scoring must be one of " + strings.Join(scoringStrategies, ", ")})
+			return
+		}
+		if req.MaxPauseSec == nil {
+			v := defaultMaxPauseSec
+			req.MaxPauseSec = &v
+		}
+		if *req.MaxPauseSec < 0 || *req.MaxPauseSec > maxMaxPauseSec {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "maxPauseSec must be between 0 and " + strconv.Itoa(maxMaxPauseSec)})
+			return
+		}
+		bank, ok := bankFromRequest(c, db, req.Bank)
+		if !ok {
+			return
+		}
+		if req.Count <= 0 {
+			req.Count = bank.DefaultQuestionCount
+			if req.Type == ExamTypeDrill {
+				req.Count = drillDefaultCount
+			}
+		}
+		if req.DurationSec <= 0 {
+			req.DurationSec = bank.DefaultDurationSec
+		}
 
 		// bind current user (if any)
 		var userID *uint
@@ -155,6 +247,21 @@ func StartExam(db *gorm.DB) gin.HandlerFunc {
 				userID = &id
 			}
 		}
+		if req.Type == ExamTypeAdaptive {
+			startAdaptiveExam(c, db, req, bank, userID)
+			return
+		}
+		if req.Type == ExamTypeDrill {
+			startDrill(c, db, req, bank, userID)
+			return
+		}
+
+		var ids []string
+		if err := db.Model(&Question{}).Where("bank_id = ?", bank.ID).Order("id").Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no questions"})
+			return
+		}
+		drawn := drawQuestions(ids, req.Count, req.Seed)
 
 		examID := uuid.New().String()
 		exam := Exam{
@@ -164,51 +271,55 @@ func StartExam(db *gorm.DB) gin.HandlerFunc {
 			DurationSeconds: req.DurationSec,
 			Seed:            req.Seed,
 			UserID:          userID,
+			BankID:          bank.ID,
+			PassPercent:     bank.PassPercent,
+			Scoring:         req.Scoring,
+			Delivery:        req.Delivery,
+			MaxPauseSeconds: *req.MaxPauseSec,
+		}
+		if req.Delivery == DeliverySequential {
+			exam.AllowBack, exam.LockAnswered = req.AllowBack, req.LockAnswered
 		}
-		if err := db.Create(&exam).Error; err != nil {
+		if err := createExam(db, &exam, drawn); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
-		for i, qid := range drawn {
-			eq := ExamQuestion{ExamID: examID, QuestionID: qid, Position: i + 1}
-			if err := db.Create(&eq).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
-				return
-			}
+		if req.Delivery == DeliverySequential {
+			// no questions up front: the client pulls them one by one
+			c.JSON(http.StatusOK, gin.H{
+				"examId":        examID,
+				"bank":          bank.ID,
+				"durationSec":   req.DurationSec,
+				"scoring":       req.Scoring,
+				"delivery":      exam.Delivery,
+				"allowBack":     exam.AllowBack,
+				"lockAnswered":  exam.LockAnswered,
+				"questionCount": len(drawn),
+				"clock":         computeClock(exam, nil, time.Now()),
+			})
+			return
 		}
 
-		// fetch questions & keep original order
-		var qs []Question
-		if err := db.Preload("Options").Where("id IN ?", drawn).Find(&qs).Error; err != nil {
+		out, err := loadQuestionDTOs(db, drawn)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
-		index := map[string]Question{}
-		for _, q := range qs {
-			index[q.ID] = q
-		}
-		out := make([]QuestionDTO, 0, len(drawn))
-		for _, id := range drawn {
-			q := index[id]
-			opts := make([]OptionDTO, 0, len(q.Options))
-			for _, o := range q.Options {
-				opts = append(opts, OptionDTO{ID: o.OptionKey, Text: o.TextEN})
-			}
-			out = append(out, QuestionDTO{
-				ID: q.ID, QuestionText: q.TextEN, MultiSelect: q.MultiSelect, Options: opts,
-			})
-		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"examId":      examID,
+			"bank":        bank.ID,
 			"durationSec": req.DurationSec,
+			"scoring":     req.Scoring,
 			"questions":   out,
+			"clock":       computeClock(exam, nil, time.Now()),
 		})
 	}
 }
 
 type ExamAnswerReq struct {
-	Selected []string `json:"selected"`
+	Selected    []string `json:"selected"`
+	TimeSpentMs *int64   `json:"timeSpentMs"` // optional dwell time measured by the client
 }
 
 func ExamAnswer(db *gorm.DB) gin.HandlerFunc {
@@ -219,9 +330,24 @@ func ExamAnswer(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "missing examId/questionId"})
 			return
 		}
-		var exam Exam
-		if err := db.First(&exam, "id = ?", examID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "exam not found"})
+		owned, found := loadExam(c, db, examID, examAccessWrite) // owner only: no answering someone else's exam
+		if !found {
+			return
+		}
+		exam := *owned
+		if !rejectIfClosed(c, db, &exam) { // finished exams are immutable, expired ones too
+			return
+		}
+		if !rejectIfPaused(c, db, &exam) {
+			return
+		}
+		// every mode: the question must belong to the exam and is answered once (overwrite)
+		prev, msg, err := checkExamAnswer(db, &exam, qid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		} else if msg != "" {
+			c.JSON(http.StatusConflict, gin.H{"error": msg})
 			return
 		}
 		var req ExamAnswerReq
@@ -232,12 +358,42 @@ func ExamAnswer(db *gorm.DB) gin.HandlerFunc {
 		for i := range req.Selected {
 			req.Selected[i] = strings.ToLower(req.Selected[i])
 		}
-		correct, err := computeCorrectKeys(db, qid)
-		if err != nil {
+		var opts []Option
+		if err := db.Where("question_id = ?", qid).Find(&opts).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
+		if serr := validateSelection(req.Selected, opts); serr != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid selection", "details": serr})
+			return
+		}
+		if exam.Type == ExamTypeDrill {
+			// drill: immediate feedback like /learn/answer
+			drillAnswer(c, db, &exam, qid, prev, req, opts)
+			return
+		}
+		if exam.Type == ExamTypeAdaptive {
+			// adaptive: only the last served question can be answered, once
+			pending, err := pendingAdaptiveQuestion(db, exam.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			if pending != qid {
+				c.JSON(http.StatusConflict, gin.H{"error": "answer the current question", "currentQuestionId": pending})
+				return
+			}
+		}
+		correct := correctKeysOf(opts)
 		ok := isCorrectAllOrNothing(req.Selected, correct)
+		points := scoreAnswer(examScoring(exam), req.Selected, correct, len(opts))
+
+		now := time.Now()
+		spent, err := measureTimeSpent(db, &exam, qid, req.TimeSpentMs, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
 
 		raw, _ := json.Marshal(req.Selected)
 		ans := Answer{
@@ -245,97 +401,337 @@ func ExamAnswer(db *gorm.DB) gin.HandlerFunc {
 			QuestionID: qid,
 			SelectedRaw: string(raw),
 			IsCorrect:  ok,
-			AnsweredAt: time.Now(),
+			AnsweredAt: now,
+			TimeSpentMs: spent,
+			Points:     &points,
+		}
+		if prev != nil {
+			ans.ID = prev.ID
+			ans.TimeSpentMs = addTimeSpent(prev.TimeSpentMs, spent) // time of all visits
+		}
+		if err := saveExamAnswer(db, &ans); errors.Is(err, errAlreadyFinished) {
+			c.JSON(http.StatusConflict, gin.H{"error": "exam already finished"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
 		}
-		if err := db.Create(&ans).Error; err != nil {
+		clock, err := examClock(db, &exam)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
+		if exam.Type == ExamTypeAdaptive {
+			next, err := advanceAdaptive(db, &exam)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			// no correctness and no ability estimate until finish
+			c.JSON(http.StatusOK, gin.H{"saved": true, "done": next == nil, "next": next, "clock": clock})
+			return
+		}
 		// Do NOT reveal correctness during exam
-		c.JSON(http.StatusOK, gin.H{"saved": true})
+		c.JSON(http.StatusOK, gin.H{"saved": true, "clock": clock})
+	}
+}
+
+// ReviewRow is one question of the post-exam review (FinishExam, GetMyExam).
+type ReviewRow struct {
+	QuestionID       string             `json:"questionId"`
+	QuestionText     string             `json:"questionText"`
+	Selected         []string           `json:"selected"`
+	Correct          []string           `json:"correct"`
+	ExplanationsEn   map[string]ExpDTO  `json:"explanationsEn"`
+	ExplanationsPl   map[string]ExpDTO  `json:"explanationsPl"`
+	WasCorrect       bool               `json:"wasCorrect"`
+	Points           float64            `json:"points"` // per the exam scoring strategy; max 1, negative with "negative"
+	CommentCount     int                `json:"commentCount"`
+	TimeSpentMs      *int64             `json:"timeSpentMs,omitempty"` // sum over visits; nil = not measured
+	SlowAndWrong     bool               `json:"slowAndWrong"`          // over the per-question budget and wrong
+}
+
+// buildReview builds review rows in exam order and counts correct answers.
+func buildReview(db *gorm.DB, examID string) ([]ReviewRow, int, error) {
+	var exam Exam
+	if err := db.First(&exam, "id = ?", examID).Error; err != nil {
+		return nil, 0, err
+	}
+	var eqs []ExamQuestion
+	if err := db.Where("exam_id = ?", examID).Order("position").Find(&eqs).Error; err != nil {
+		return nil, 0, err
+	}
+	slowMs := slowThresholdMs(&exam, len(eqs))
+
+	// time spent per question: the answer sums all visits
+	type timeRow struct {
+		QuestionID string
+		Ms         int64
+		N          int
+	}
+	var trows []timeRow
+	if err := db.Model(&Answer{}).
+		Select("question_id as question_id, SUM(time_spent_ms) as ms, COUNT(time_spent_ms) as n").
+		Where("exam_id = ?", examID).Group("question_id").Scan(&trows).Error; err != nil {
+		return nil, 0, err
+	}
+	spent := map[string]*int64{}
+	for _, r := range trows {
+		if r.N > 0 {
+			ms := r.Ms
+			spent[r.QuestionID] = &ms
+		}
+	}
+
+	toMap := func(xs []Explanation) map[string]ExpDTO {
+		m := map[string]ExpDTO{}
+		for _, e := range xs {
+			m[e.OptionKey] = ExpDTO{Text: e.Text, URL: e.URL}
+		}
+		return m
 	}
+
+	qids := make([]string, 0, len(eqs))
+	for _, eq := range eqs { qids = append(qids, eq.QuestionID) }
+	comments, err := commentCounts(db, qids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	review := []ReviewRow{}
+	correctCount := 0
+	for _, eq := range eqs {
+		var q Question
+		if err := db.First(&q, "id = ?", eq.QuestionID).Error; err != nil {
+			continue
+		}
+		var a Answer
+		_ = db.Where("exam_id = ? AND question_id = ?", examID, q.ID).First(&a).Error
+
+		var selected []string
+		_ = json.Unmarshal([]byte(a.SelectedRaw), &selected)
+		correctKeys, _ := computeCorrectKeys(db, q.ID)
+		if a.IsCorrect { correctCount++ }
+
+		var exEN, exPL []Explanation
+		_ = db.Where("question_id = ? AND lang = 'en'", q.ID).Find(&exEN).Error
+		_ = db.Where("question_id = ? AND lang = 'pl'", q.ID).Find(&exPL).Error
+
+		review = append(review, ReviewRow{
+			QuestionID:     q.ID,
+			QuestionText:   q.TextEN,
+			Selected:       selected,
+			Correct:        correctKeys,
+			ExplanationsEn: toMap(exEN),
+			ExplanationsPl: toMap(exPL),
+			WasCorrect:     a.IsCorrect,
+			Points:         answerPoints(a),
+			CommentCount:   comments[q.ID],
+			TimeSpentMs:    spent[q.ID],
+			SlowAndWrong:   !a.IsCorrect && spent[q.ID] != nil && *spent[q.ID] > slowMs,
+		})
+	}
+	return review, correctCount, nil
+}
+
+// OpenReviewRow is a review row of an unfinished exam: what was answered, without the answer key.
+type OpenReviewRow struct {
+	QuestionID   string   `json:"questionId"`
+	QuestionText string   `json:"questionText"`
+	Selected     []string `json:"selected"`
+	CommentCount int      `json:"commentCount"`
+	TimeSpentMs  *int64   `json:"timeSpentMs,omitempty"`
+}
+
+// servedReview trims the review of an unfinished exam to what the user has already seen:
+// sequential exams only up to CurrentPosition, and nothing that tells right from wrong.
+func servedReview(db *gorm.DB, exam *Exam, review []ReviewRow) ([]ReviewRow, []OpenReviewRow, error) {
+	served := map[string]bool{}
+	if examDelivery(*exam) == DeliverySequential {
+		var qids []string
+		if err := db.Model(&ExamQuestion{}).Where("exam_id = ? AND position <= ?", exam.ID, exam.CurrentPosition).
+			Pluck("question_id", &qids).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, id := range qids { served[id] = true }
+	}
+	rows := []ReviewRow{}
+	open := []OpenReviewRow{}
+	for _, r := range review {
+		if examDelivery(*exam) == DeliverySequential && !served[r.QuestionID] {
+			continue
+		}
+		rows = append(rows, ReviewRow{QuestionID: r.QuestionID, TimeSpentMs: r.TimeSpentMs}) // timing only
+		open = append(open, OpenReviewRow{
+			QuestionID:   r.QuestionID,
+			QuestionText: r.QuestionText,
+			Selected:     r.Selected,
+			CommentCount: r.CommentCount,
+			TimeSpentMs:  r.TimeSpentMs,
+		})
+	}
+	return rows, open, nil
+}
+
+// errAlreadyFinished signals that another request finished the exam first.
+var errAlreadyFinished = errors.New("exam already finished")
+
+// errExamClosed signals that the exam was abandoned before finish.
+var errExamClosed = errors.New("exam is closed")
+
+// finishExam scores and closes the exam in one transaction. The update only applies
+// while finished_at is still NULL, so concurrent finish calls store a single result.
+func finishExam(db *gorm.DB, examID string, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var exam Exam
+		if err := tx.First(&exam, "id = ?", examID).Error; err != nil {
+			return err
+		}
+		if exam.FinishedAt != nil {
+			return errAlreadyFinished
+		}
+		if exam.Status == ExamStatusAbandoned { // expired: scored with the answers given in time
+			return errExamClosed
+		}
+		score, _, _, err := computeExamScore(tx, examID)
+		if err != nil {
+			return err
+		}
+		// finishing while paused ends the pause at the same moment
+		if p, err := openPause(tx, examID); err != nil {
+			return err
+		} else if p != nil {
+			if err := closePause(tx, p, now); err != nil {
+				return err
+			}
+		}
+		updates := map[string]interface{}{"finished_at": now, "status": ExamStatusFinished}
+		if exam.Type == ExamTypeAdaptive {
+			// adaptive: the ability estimate replaces the percentage (questions differ in difficulty)
+			ability, _, err := abilityOf(tx, &exam)
+			if err != nil {
+				return err
+			}
+			updates["ability_theta"], updates["ability_se"] = ability.Theta, ability.SE
+		} else {
+			updates["score_percent"] = score
+		}
+		res := tx.Model(&Exam{}).Where("id = ? AND finished_at IS NULL", examID).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errAlreadyFinished
+		}
+		return nil
+	})
+}
+
+// saveExamAnswer stores the answer (ID set = overwrite) only while the exam is still open,
+// so an answer racing with finish can't change a stored result.
+func saveExamAnswer(db *gorm.DB, ans *Answer) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var open int64
+		if err := tx.Model(&Exam{}).Where("id = ? AND finished_at IS NULL", ans.ExamID).Count(&open).Error; err != nil {
+			return err
+		}
+		if open == 0 {
+			return errAlreadyFinished
+		}
+		if ans.ID != 0 {
+			return tx.Save(ans).Error
+		}
+		return tx.Create(ans).Error
+	})
 }
 
+// FinishExam closes the exam and returns the result. Calling it again on a finished
+// exam returns the stored result unchanged (alreadyFinished: true).
 func FinishExam(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		examID := c.Param("id")
+		owned, ok := loadExam(c, db, examID, examAccessWrite) // the result reveals the answer key
+		if !ok {
+			return
+		}
+		already := owned.FinishedAt != nil
+		if !already {
+			// abandoned: no result; out of time: finished at the deadline, with the answers
+			// given until then (later ones were rejected)
+			if owned.Status == ExamStatusAbandoned {
+				c.JSON(http.StatusConflict, gin.H{"error": examClosedError(*owned)})
+				return
+			}
+			at, err := finishTime(db, owned, time.Now())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			err = finishExam(db, examID, at)
+			switch {
+			case errors.Is(err, errAlreadyFinished):
+				already = true
+			case errors.Is(err, errExamClosed):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			case errors.Is(err, errNoAnswers):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			case err != nil:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+		}
+
+		// the response always comes from the stored exam
 		var exam Exam
 		if err := db.First(&exam, "id = ?", examID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "exam not found"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
-		score, correct, wrong, err := computeExamScore(db, examID)
+		_, correct, wrong, err := computeExamScore(db, examID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		now := time.Now()
-		exam.FinishedAt = &now
-		exam.ScorePercent = &score
-		if err := db.Save(&exam).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
-
-		type ReviewRow struct {
-			QuestionID       string             `json:"questionId"`
-			QuestionText     string             `json:"questionText"`
-			Selected         []string           `json:"selected"`
-			Correct          []string           `json:"correct"`
-			ExplanationsEn   map[string]ExpDTO  `json:"explanationsEn"`
-			ExplanationsPl   map[string]ExpDTO  `json:"explanationsPl"`
-			WasCorrect       bool               `json:"wasCorrect"`
+		var ability *AbilityEstimate
+		if exam.Type == ExamTypeAdaptive && exam.AbilityTheta != nil && exam.AbilitySE != nil {
+			if ability, err = newAbilityEstimate(db, exam.BankID, *exam.AbilityTheta, *exam.AbilitySE); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
 		}
-
-		var eqs []ExamQuestion
-		if err := db.Where("exam_id = ?", examID).Order("position").Find(&eqs).Error; err != nil {
+		review, _, err := buildReview(db, examID)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
-
-		toMap := func(xs []Explanation) map[string]ExpDTO {
-			m := map[string]ExpDTO{}
-			for _, e := range xs {
-				m[e.OptionKey] = ExpDTO{Text: e.Text, URL: e.URL}
-			}
-			return m
+		clock, err := examClock(db, &exam)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
 		}
-
-		review := []ReviewRow{}
-		for _, eq := range eqs {
-			var q Question
-			if err := db.First(&q, "id = ?", eq.QuestionID).Error; err != nil {
-				continue
-			}
-			var a Answer
-			_ = db.Where("exam_id = ? AND question_id = ?", examID, q.ID).First(&a).Error
-
-			var selected []string
-			_ = json.Unmarshal([]byte(a.SelectedRaw), &selected)
-
-			correctKeys, _ := computeCorrectKeys(db, q.ID)
-
-			var exEN, exPL []Explanation
-			_ = db.Where("question_id = ? AND lang = 'en'", q.ID).Find(&exEN).Error
-			_ = db.Where("question_id = ? AND lang = 'pl'", q.ID).Find(&exPL).Error
-
-			review = append(review, ReviewRow{
-				QuestionID:     q.ID,
-				QuestionText:   q.TextEN,
-				Selected:       selected,
-				Correct:        correctKeys,
-				ExplanationsEn: toMap(exEN),
-				ExplanationsPl: toMap(exPL),
-				WasCorrect:     a.IsCorrect,
-			})
+		drill, err := drillSummaryOf(db, &exam, review)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"scorePercent": score,
-			"correct":      correct,
-			"wrong":        wrong,
-			"passed":       passedPtr(exam.ScorePercent),
-			"items":        review,
+			"type":            exam.Type,
+			"status":          exam.Status,
+			"alreadyFinished": already,
+			"finishedAt":      exam.FinishedAt,
+			"scorePercent":    exam.ScorePercent,
+			"ability":         ability,
+			"correct":         correct,
+			"wrong":           wrong,
+			"passed":          examPassed(exam),
+			"scoring":         examScoring(exam),
+			"timing":          summarizeTiming(review, slowThresholdMs(&exam, len(review))),
+			"clock":           clock,
+			"drill":           drill,
+			"items":           review,
 		})
 	}
 }
@@ -344,15 +740,124 @@ func FinishExam(db *gorm.DB) gin.HandlerFunc {
 
 type ExamSummaryDTO struct {
     ID              string   `json:"id"`
+    Type            string   `json:"type"`
+    Status          string   `json:"status"`
+    ParentExamID    *string  `json:"parentExamId,omitempty"` // retake of this exam
+    Bank            string   `json:"bank,omitempty"`
     StartedAt       time.Time `json:"startedAt"`
     FinishedAt      *time.Time `json:"finishedAt,omitempty"`
     DurationSec     int      `json:"durationSec"`
     ScorePercent    *float64 `json:"scorePercent,omitempty"`
     QuestionCount   int      `json:"questionCount"`
+    Passed          *bool    `json:"passed,omitempty"`
+    AvgTimeMs       *float64 `json:"avgTimeMs,omitempty"` // average time per timed answer
+    Ability         *float64 `json:"ability,omitempty"`   // adaptive: final ability estimate (logits)
+    RemainingSec    int      `json:"remainingSec"`        // clock with pauses deducted; 0 once time is up
+}
+
+// parsePagination reads ?limit=20&offset=0  (limit default 20, max 100)
+func parsePagination(c *gin.Context) (limit, offset int) {
+	limit = 20
+	if l := c.Query("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			if n > 100 { n = 100 }
+			limit = n
+		}
+	}
+	if o := c.Query("offset"); o != "" {
+		if n, err := strconv.Atoi(o); err == nil && n >= 0 {
+			offset = n
+		}
+	}
+	return limit, offset
+}
+
+// listExamSummaries returns one page of a user's exams (newest first) and the total count.
+// statuses limits the list to the given Exam.Status values (nil = all).
+func listExamSummaries(db *gorm.DB, uid uint, statuses []string, limit, offset int) ([]ExamSummaryDTO, int64, error) {
+	// mark timed-out exams first so the status filter sees them
+	if err := expireExams(db, uid, time.Now()); err != nil {
+		return nil, 0, err
+	}
+	scope := func() *gorm.DB {
+		tx := db.Model(&Exam{}).Where("user_id = ?", uid)
+		if len(statuses) > 0 {
+			tx = tx.Where("status IN ?", statuses)
+		}
+		return tx
+	}
+
+	// total
+	var total int64
+	if err := scope().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// page items
+	var exams []Exam
+	if err := scope().
+		Order("started_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&exams).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// count questions per exam
+	ids := make([]string, 0, len(exams))
+	for _, e := range exams { ids = append(ids, e.ID) }
+
+	counts := map[string]int{}
+	avgTimes := map[string]*float64{}
+	if len(ids) > 0 {
+		type Row struct{ ExamID string; C int }
+		var rows []Row
+		if err := db.Table("exam_questions").
+			Select("exam_id as exam_id, COUNT(*) as c").
+			Where("exam_id IN ?", ids).
+			Group("exam_id").
+			Scan(&rows).Error; err == nil {
+			for _, r := range rows { counts[r.ExamID] = r.C }
+		}
+		type TimeRow struct{ ExamID string; Avg *float64 }
+		var trows []TimeRow
+		if err := db.Table("answers").
+			Select("exam_id as exam_id, AVG(time_spent_ms) as avg").
+			Where("exam_id IN ?", ids).
+			Group("exam_id").
+			Scan(&trows).Error; err == nil {
+			for _, r := range trows { avgTimes[r.ExamID] = r.Avg }
+		}
+	}
+	pauses, err := loadPauses(db, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+
+	items := make([]ExamSummaryDTO, 0, len(exams))
+	for _, e := range exams {
+		items = append(items, ExamSummaryDTO{
+			ID:            e.ID,
+			Type:          e.Type,
+			Status:        e.Status,
+			ParentExamID:  e.ParentExamID,
+			Bank:          e.BankID,
+			StartedAt:     e.StartedAt,
+			FinishedAt:    e.FinishedAt,
+			DurationSec:   e.DurationSeconds,
+			ScorePercent:  e.ScorePercent,
+			QuestionCount: counts[e.ID],
+			Passed:        examPassed(e),
+			AvgTimeMs:     avgTimes[e.ID],
+			Ability:       abilityIfFinished(e),
+			RemainingSec:  computeClock(e, pauses[e.ID], now).RemainingSec,
+		})
+	}
+	return items, total, nil
 }
 
 // ListMyExams returns user exams with pagination.
-// Query params: ?limit=20&offset=0  (limit default 20, max 100)
+// Query params: ?limit=20&offset=0  (limit default 20, max 100), ?status=in_progress,expired
 func ListMyExams(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// auth
@@ -363,78 +868,17 @@ func ListMyExams(db *gorm.DB) gin.HandlerFunc {
 		}
 		uid := v.(uint)
 
-		// pagination
-		limit := 20
-		offset := 0
-		if l := c.Query("limit"); l != "" {
-			if n, err := strconv.Atoi(l); err == nil && n > 0 {
-				if n > 100 { n = 100 }
-				limit = n
-			}
-		}
-		if o := c.Query("offset"); o != "" {
-			if n, err := strconv.Atoi(o); err == nil && n >= 0 {
-				offset = n
-			}
-		}
-
-		// total
-		var total int64
-		if err := db.Model(&Exam{}).Where("user_id = ?", uid).Count(&total).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		statuses, ok := parseStatusFilter(c)
+		if !ok {
 			return
 		}
-
-		// page items
-		var exams []Exam
-		if err := db.Where("user_id = ?", uid).
-			Order("started_at DESC").
-			Limit(limit).Offset(offset).
-			Find(&exams).Error; err != nil {
+		limit, offset := parsePagination(c)
+		items, total, err := listExamSummaries(db, uid, statuses, limit, offset)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
 
-		// count questions per exam
-		ids := make([]string, 0, len(exams))
-		for _, e := range exams { ids = append(ids, e.ID) }
-
-		counts := map[string]int{}
-		if len(ids) > 0 {
-			type Row struct{ ExamID string; C int }
-			var rows []Row
-			if err := db.Table("exam_questions").
-				Select("exam_id as exam_id, COUNT(*) as c").
-				Where("exam_id IN ?", ids).
-				Group("exam_id").
-				Scan(&rows).Error; err == nil {
-				for _, r := range rows { counts[r.ExamID] = r.C }
-			}
-		}
-
-		type ExamSummaryDTO struct {
-			ID            string     `json:"id"`
-			StartedAt     time.Time  `json:"startedAt"`
-			FinishedAt    *time.Time `json:"finishedAt,omitempty"`
-			DurationSec   int        `json:"durationSec"`
-			ScorePercent  *float64   `json:"scorePercent,omitempty"`
-			QuestionCount int        `json:"questionCount"`
-			Passed        *bool      `json:"passed,omitempty"`
-		}
-
-		items := make([]ExamSummaryDTO, 0, len(exams))
-		for _, e := range exams {
-			items = append(items, ExamSummaryDTO{
-				ID:            e.ID,
-				StartedAt:     e.StartedAt,
-				FinishedAt:    e.FinishedAt,
-				DurationSec:   e.DurationSeconds,
-				ScorePercent:  e.ScorePercent,
-				QuestionCount: counts[e.ID],
-				Passed:        passedPtr(e.ScorePercent),
-			})
-		}
-
 		c.JSON(http.StatusOK, gin.H{
 			"total":  total,
 			"limit":  limit,
@@ -448,93 +892,95 @@ func GetMyExam(db *gorm.DB) gin.HandlerFunc {
     return func(c *gin.Context) {
         examID := c.Param("id")
 
-        // auth & ownership
-        v, ok := c.Get("userDBID")
+        // owner, the owner's group trainer or an admin
+        found, ok := loadExam(c, db, examID, examAccessRead)
         if !ok {
-            c.JSON(http.StatusUnauthorized, gin.H{"error":"no user"})
             return
         }
-        uid := v.(uint)
+        exam := *found
 
-        var exam Exam
-        if err := db.First(&exam, "id = ?", examID).Error; err != nil {
-            c.JSON(http.StatusNotFound, gin.H{"error":"exam not found"})
-            return
-        }
-        if exam.UserID == nil || *exam.UserID != uid {
-            c.JSON(http.StatusForbidden, gin.H{"error":"forbidden"})
+        // Build the same review payload as in FinishExam (read-only)
+        review, correctCount, err := buildReview(db, examID)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error":"db"})
             return
         }
 
-        // Build the same review payload as in FinishExam (read-only)
-        type ReviewRow struct {
-            QuestionID     string            `json:"questionId"`
-            QuestionText   string            `json:"questionText"`
-            Selected       []string          `json:"selected"`
-            Correct        []string          `json:"correct"`
-            ExplanationsEn map[string]ExpDTO `json:"explanationsEn"`
-            ExplanationsPl map[string]ExpDTO `json:"explanationsPl"`
-            WasCorrect     bool              `json:"wasCorrect"`
-        }
+        var total int64
+        _ = db.Model(&Answer{}).Where("exam_id = ?", examID).Count(&total).Error
 
-        // order of questions
-        var eqs []ExamQuestion
-        if err := db.Where("exam_id = ?", examID).Order("position").Find(&eqs).Error; err != nil {
+        var ability *AbilityEstimate
+        if exam.Type == ExamTypeAdaptive && exam.FinishedAt != nil && exam.AbilityTheta != nil && exam.AbilitySE != nil {
+            ability, _ = newAbilityEstimate(db, exam.BankID, *exam.AbilityTheta, *exam.AbilitySE) // as stored at finish
+        }
+        clock, err := examClock(db, &exam)
+        if err != nil {
             c.JSON(http.StatusInternalServerError, gin.H{"error":"db"})
             return
         }
-
-        // helper
-        toMap := func(xs []Explanation) map[string]ExpDTO {
-            m := map[string]ExpDTO{}
-            for _, e := range xs {
-                m[e.OptionKey] = ExpDTO{Text: e.Text, URL: e.URL}
+        if exam.Status == ExamStatusInProgress && clock.RemainingSec <= 0 {
+            // only the owner's read stores the change; trainers and admins just see it
+            if uid, _ := currentUserID(c); exam.UserID != nil && *exam.UserID == uid {
+                if err := db.Model(&exam).Update("status", ExamStatusExpired).Error; err != nil {
+                    c.JSON(http.StatusInternalServerError, gin.H{"error":"db"})
+                    return
+                }
             }
-            return m
+            exam.Status = ExamStatusExpired
         }
-
-        review := []ReviewRow{}
-        correctCount := 0
-        for _, eq := range eqs {
-            var q Question
-            if err := db.First(&q, "id = ?", eq.QuestionID).Error; err != nil {
-                continue
+        drill, err := drillSummaryOf(db, &exam, review)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error":"db"})
+            return
+        }
+        slowMs := slowThresholdMs(&exam, len(review))
+
+        // until finish: no answer key, and sequential exams only show served questions
+        if exam.FinishedAt == nil {
+            timed, items, err := servedReview(db, &exam, review)
+            if err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{"error":"db"})
+                return
             }
-            var a Answer
-            _ = db.Where("exam_id = ? AND question_id = ?", examID, q.ID).First(&a).Error
-
-            var selected []string
-            _ = json.Unmarshal([]byte(a.SelectedRaw), &selected)
-            correctKeys, _ := computeCorrectKeys(db, q.ID)
-            if a.IsCorrect { correctCount++ }
-
-            var exEN, exPL []Explanation
-            _ = db.Where("question_id = ? AND lang = 'en'", q.ID).Find(&exEN).Error
-            _ = db.Where("question_id = ? AND lang = 'pl'", q.ID).Find(&exPL).Error
-
-            review = append(review, ReviewRow{
-                QuestionID:     q.ID,
-                QuestionText:   q.TextEN,
-                Selected:       selected,
-                Correct:        correctKeys,
-                ExplanationsEn: toMap(exEN),
-                ExplanationsPl: toMap(exPL),
-                WasCorrect:     a.IsCorrect,
+            c.JSON(http.StatusOK, gin.H{
+                "examId":       exam.ID,
+                "type":         exam.Type,
+                "status":       exam.Status,
+                "parentExamId": exam.ParentExamID,
+                "retakeMode":   exam.RetakeMode,
+                "bank":         exam.BankID,
+                "startedAt":    exam.StartedAt,
+                "finishedAt":   exam.FinishedAt,
+                "durationSec":  exam.DurationSeconds,
+                "scoring":      examScoring(exam),
+                "answered":     total,
+                "timing":       summarizeTiming(timed, slowMs),
+                "clock":        clock,
+                "drill":        drill,
+                "items":        items,
             })
+            return
         }
 
-        var total int64
-        _ = db.Model(&Answer{}).Where("exam_id = ?", examID).Count(&total).Error
-
         c.JSON(http.StatusOK, gin.H{
             "examId":       exam.ID,
+            "type":         exam.Type,
+            "status":       exam.Status,
+            "parentExamId": exam.ParentExamID,
+            "retakeMode":   exam.RetakeMode,
+            "bank":         exam.BankID,
             "startedAt":    exam.StartedAt,
             "finishedAt":   exam.FinishedAt,
             "durationSec":  exam.DurationSeconds,
             "scorePercent": exam.ScorePercent,
-			"passed":       passedPtr(exam.ScorePercent),
+            "ability":      ability,
+			"passed":       examPassed(exam),
+            "scoring":      examScoring(exam),
             "correct":      correctCount,
             "wrong":        int(total) - correctCount,
+            "timing":       summarizeTiming(review, slowMs),
+            "clock":        clock,
+            "drill":        drill,
             "items":        review,
         })
     }
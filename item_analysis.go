@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Klasyczna analiza pytań (CTT) liczona z odpowiedzi w zakończonych egzaminach.
+
+const (
+	flagNegativeDiscrimination = "negative_discrimination" // lepsi zdający wypadają gorzej niż słabsi
+	flagUnusedDistractor       = "unused_distractor"       // błędna opcja, której nikt nie wybiera
+)
+
+// itemResponse to jedna odpowiedź na pytanie wraz z wynikiem całego egzaminu.
+type itemResponse struct {
+	QuestionID string
+	Selected   []string
+	IsCorrect  bool
+	ExamScore  float64
+}
+
+type OptionStat struct {
+	Key       string  `json:"key"`
+	IsCorrect bool    `json:"isCorrect"`
+	Picked    int     `json:"picked"`
+	Frequency float64 `json:"frequency"` // % odpowiedzi, w których opcję zaznaczono
+}
+
+type ItemStats struct {
+	QuestionID        string       `json:"questionId"`
+	Answered          int          `json:"answered"`
+	PValue            float64      `json:"pValue"`                   // % poprawnych
+	PointBiserial     *float64     `json:"pointBiserial,omitempty"`  // korelacja poprawności z wynikiem egzaminu
+	UpperPValue       *float64     `json:"upperPValue,omitempty"`    // % poprawnych w górnych 27% wyników
+	LowerPValue       *float64     `json:"lowerPValue,omitempty"`    // % poprawnych w dolnych 27% wyników
+	Discrimination    *float64     `json:"discrimination,omitempty"` // upper - lower (w punktach procentowych)
+	Options           []OptionStat `json:"options"`
+	UnusedDistractors []string     `json:"unusedDistractors"`
+	Flags             []string     `json:"flags"`
+}
+
+// computeItemStats liczy statystyki per pytanie. options to opcje pytań
+// (potrzebne, żeby wykryć dystraktory, których nikt nie wybrał).
+func computeItemStats(responses []itemResponse, options map[string][]Option) []ItemStats {
+	byQ := map[string][]itemResponse{}
+	for _, r := range responses {
+		byQ[r.QuestionID] = append(byQ[r.QuestionID], r)
+	}
+
+	out := make([]ItemStats, 0, len(byQ))
+	for qid, rs := range byQ {
+		st := ItemStats{QuestionID: qid, Answered: len(rs), Options: []OptionStat{}, UnusedDistractors: []string{}, Flags: []string{}}
+
+		correct := 0
+		picked := map[string]int{}
+		for _, r := range rs {
+			if r.IsCorrect {
+				correct++
+			}
+			seen := map[string]bool{}
+			for _, k := range r.Selected {
+				if !seen[k] {
+					seen[k] = true
+					picked[k]++
+				}
+			}
+		}
+		p := float64(correct) / float64(len(rs))
+		st.PValue = p * 100
+
+		st.PointBiserial = pointBiserial(rs, p)
+
+		upper, lower := upperLowerPValues(rs)
+		if upper != nil && lower != nil {
+			st.UpperPValue, st.LowerPValue = upper, lower
+			d := *upper - *lower
+			st.Discrimination = &d
+		}
+		if (st.Discrimination != nil && *st.Discrimination < 0) || (st.PointBiserial != nil && *st.PointBiserial < 0) {
+			st.Flags = append(st.Flags, flagNegativeDiscrimination)
+		}
+
+		for _, o := range options[qid] {
+			n := picked[o.OptionKey]
+			st.Options = append(st.Options, OptionStat{
+				Key:       o.OptionKey,
+				IsCorrect: o.IsCorrect,
+				Picked:    n,
+				Frequency: float64(n) * 100 / float64(len(rs)),
+			})
+			if !o.IsCorrect && n == 0 {
+				st.UnusedDistractors = append(st.UnusedDistractors, o.OptionKey)
+			}
+		}
+		sort.Slice(st.Options, func(i, j int) bool { return st.Options[i].Key < st.Options[j].Key })
+		sort.Strings(st.UnusedDistractors)
+		if len(st.UnusedDistractors) > 0 {
+			st.Flags = append(st.Flags, flagUnusedDistractor)
+		}
+
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].QuestionID < out[j].QuestionID })
+	return out
+}
+
+// pointBiserial: (M1 - M0) / s * sqrt(p*q), gdzie M1/M0 to średni wynik egzaminu
+// osób z poprawną/błędną odpowiedzią, s to odchylenie standardowe wyników.
+// nil, gdy wszyscy odpowiedzieli tak samo albo wyniki się nie różnią.
+func pointBiserial(rs []itemResponse, p float64) *float64 {
+	if p == 0 || p == 1 {
+		return nil
+	}
+	var sum, sum1, sum0 float64
+	var n1, n0 int
+	for _, r := range rs {
+		sum += r.ExamScore
+		if r.IsCorrect {
+			sum1 += r.ExamScore
+			n1++
+		} else {
+			sum0 += r.ExamScore
+			n0++
+		}
+	}
+	mean := sum / float64(len(rs))
+	var ss float64
+	for _, r := range rs {
+		ss += (r.ExamScore - mean) * (r.ExamScore - mean)
+	}
+	sd := math.Sqrt(ss / float64(len(rs)))
+	if sd == 0 {
+		return nil
+	}
+	v := (sum1/float64(n1) - sum0/float64(n0)) / sd * math.Sqrt(p*(1-p))
+	return &v
+}
+
+// upperLowerPValues porównuje % poprawnych w górnych i dolnych 27% wyników egzaminu.
+// Wymaga min. 4 odpowiedzi, żeby grupy były rozłączne i niepuste.
+func upperLowerPValues(rs []itemResponse) (*float64, *float64) {
+	if len(rs) < 4 {
+		return nil, nil
+	}
+	sorted := append([]itemResponse(nil), rs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ExamScore > sorted[j].ExamScore })
+	k := int(math.Round(float64(len(sorted)) * 0.27))
+	if k < 1 {
+		k = 1
+	}
+	pct := func(xs []itemResponse) *float64 {
+		c := 0
+		for _, r := range xs {
+			if r.IsCorrect {
+				c++
+			}
+		}
+		v := float64(c) * 100 / float64(len(xs))
+		return &v
+	}
+	return pct(sorted[:k]), pct(sorted[len(sorted)-k:])
+}
+
+// loadItemResponses ładuje odpowiedzi z zakończonych egzaminów (z wynikiem); bankID "" = wszystkie banki.
+func loadItemResponses(db *gorm.DB, bankID string) ([]itemResponse, error) {
+	type Row struct {
+		QuestionID  string
+		SelectedRaw string
+		IsCorrect   bool
+		Score       float64
+	}
+	var rows []Row
+	tx := db.Table("answers a").
+		Select("a.question_id as question_id, a.selected_raw as selected_raw, a.is_correct as is_correct, e.score_percent as score").
+		Joins("JOIN exams e ON e.id = a.exam_id").
+		Where("e.score_percent IS NOT NULL AND e.type <> ?", ExamTypeDrill) // drill: feedback po każdym pytaniu
+	if bankID != "" {
+		tx = tx.Where("e.bank_id = ?", bankID)
+	}
+	if err := tx.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]itemResponse, 0, len(rows))
+	for _, r := range rows {
+		var sel []string
+		_ = json.Unmarshal([]byte(r.SelectedRaw), &sel)
+		out = append(out, itemResponse{QuestionID: r.QuestionID, Selected: sel, IsCorrect: r.IsCorrect, ExamScore: r.Score})
+	}
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestComputeItemStats(t *testing.T) {
+	options := map[string][]Option{
+		"q1": {
+			{OptionKey: "a", IsCorrect: true},
+			{OptionKey: "b"},
+			{OptionKey: "c"},
+			{OptionKey: "d"},
+		},
+	}
+	// high scorers answer correctly, low scorers pick "b"; nobody picks "c" or "d"
+	responses := []itemResponse{
+		{QuestionID: "q1", Selected: []string{"a"}, IsCorrect: true, ExamScore: 90},
+		{QuestionID: "q1", Selected: []string{"a"}, IsCorrect: true, ExamScore: 80},
+		{QuestionID: "q1", Selected: []string{"a"}, IsCorrect: true, ExamScore: 70},
+		{QuestionID: "q1", Selected: []string{"b"}, IsCorrect: false, ExamScore: 40},
+		{QuestionID: "q1", Selected: []string{"b"}, IsCorrect: false, ExamScore: 30},
+	}
+	items := computeItemStats(responses, options)
+	if len(items) != 1 {
+		t.Fatalf("items = %d, want 1", len(items))
+	}
+	it := items[0]
+	if it.Answered != 5 || it.PValue != 60 {
+		t.Errorf("answered = %d, pValue = %v, want 5 and 60", it.Answered, it.PValue)
+	}
+	if it.PointBiserial == nil || *it.PointBiserial <= 0.9 {
+		t.Errorf("pointBiserial = %v, want strongly positive", it.PointBiserial)
+	}
+	if it.Discrimination == nil || *it.Discrimination != 100 {
+		t.Errorf("discrimination = %v, want 100", it.Discrimination)
+	}
+	if !reflect.DeepEqual(it.UnusedDistractors, []string{"c", "d"}) {
+		t.Errorf("unusedDistractors = %v, want [c d]", it.UnusedDistractors)
+	}
+	if !reflect.DeepEqual(it.Flags, []string{flagUnusedDistractor}) {
+		t.Errorf("flags = %v, want [%s]", it.Flags, flagUnusedDistractor)
+	}
+	if got := it.Options[1]; got.Key != "b" || got.Picked != 2 || math.Abs(got.Frequency-40) > 1e-9 {
+		t.Errorf("option b = %+v, want picked 2 (40%%)", got)
+	}
+}
+
+func TestComputeItemStatsNegativeDiscrimination(t *testing.T) {
+	responses := []itemResponse{
+		{QuestionID: "q1", IsCorrect: false, ExamScore: 95},
+		{QuestionID: "q1", IsCorrect: false, ExamScore: 85},
+		{QuestionID: "q1", IsCorrect: true, ExamScore: 50},
+		{QuestionID: "q1", IsCorrect: true, ExamScore: 40},
+	}
+	it := computeItemStats(responses, nil)[0]
+	if it.PointBiserial == nil || *it.PointBiserial >= 0 {
+		t.Errorf("pointBiserial = %v, want negative", it.PointBiserial)
+	}
+	if !containsString(it.Flags, flagNegativeDiscrimination) {
+		t.Errorf("flags = %v, want %s", it.Flags, flagNegativeDiscrimination)
+	}
+}
+
+func TestQuestionIDLess(t *testing.T) {
+	ids := []string{"dev:2", "cx:10", "cx:abc", "cx:2", "dev:001", "cx:002b"}
+	sort.Slice(ids, func(i, j int) bool { return questionIDLess(ids[i], ids[j]) })
+	want := []string{"cx:2", "cx:10", "cx:002b", "cx:abc", "dev:001", "dev:2"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("sorted = %v, want %v", ids, want)
+	}
+}
+
+func TestLoadItemResponsesByBank(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	score := 50.0
+	for _, bank := range []string{"cx", "dev"} {
+		id := "e-" + bank
+		db.Create(&Exam{ID: id, Type: "exam", BankID: bank, StartedAt: now, FinishedAt: &now, DurationSeconds: 60, ScorePercent: &score, Status: ExamStatusFinished})
+		db.Create(&Answer{ExamID: id, QuestionID: bank + ":001", SelectedRaw: `["a"]`, AnsweredAt: now})
+	}
+	all, err := loadItemResponses(db, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err := loadItemResponses(db, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || len(dev) != 1 || dev[0].QuestionID != "dev:001" {
+		t.Errorf("all = %v, dev = %v", all, dev)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	BoardWeeklyAnswers = "weekly_answers" // odpowiedzi w bieżącym tygodniu (pon–nd, UTC), bez drilli
+	BoardAccuracy      = "accuracy"       // skuteczność ogółem, od leaderboardMinAnswers odpowiedzi
+	BoardBestExam      = "best_exam"      // najlepszy wynik ukończonego egzaminu próbnego
+
+	leaderboardMinAnswers = 50 // próg wolumenu w rankingu skuteczności
+)
+
+var leaderboardBoards = []string{BoardWeeklyAnswers, BoardAccuracy, BoardBestExam}
+
+// leaderboardUsers to userzy widoczni w rankingach: zgoda + ustawiona nazwa.
+func leaderboardUsers(db *gorm.DB) *gorm.DB {
+	return db.Model(&User{}).Select("id").Where("leaderboard_opt_in = ? AND display_name IS NOT NULL", true)
+}
+
+// RefreshLeaderboards przelicza wszystkie rankingi od zera w jednej transakcji.
+func RefreshLeaderboards(db *gorm.DB, now time.Time) error {
+	weekStart := bucketStart(localDay(now, time.UTC), "week")
+	type row struct {
+		UserID  uint
+		Total   int64
+		Correct int64
+		Best    float64
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&LeaderboardEntry{}).Error; err != nil {
+			return err
+		}
+		var entries []LeaderboardEntry
+
+		// jedna (najnowsza) odpowiedź na pytanie egzaminu, więc ponowne wysłanie nie podbija rankingu;
+		// drille (natychmiastowy feedback) liczone są osobno, jak w statystykach
+		latest := tx.Model(&Answer{}).Select("MAX(id)").Group("exam_id, question_id")
+		answers := func() *gorm.DB {
+			return tx.Table("answers a").
+				Select("e.user_id as user_id, COUNT(*) as total, SUM(CASE WHEN a.is_correct THEN 1 ELSE 0 END) as correct").
+				Joins("JOIN exams e ON e.id = a.exam_id").
+				Where("a.id IN (?)", latest).
+				Where("e.user_id IN (?) AND e.status <> ? AND e.type <> ?", leaderboardUsers(tx), ExamStatusAbandoned, ExamTypeDrill).
+				Group("e.user_id")
+		}
+		var weekly []row
+		if err := answers().Where("a.answered_at >= ?", weekStart).Scan(&weekly).Error; err != nil {
+			return err
+		}
+		for _, r := range weekly {
+			entries = append(entries, LeaderboardEntry{Board: BoardWeeklyAnswers, UserID: r.UserID, Value: float64(r.Total), Volume: r.Total, ComputedAt: now})
+		}
+
+		var overall []row
+		if err := answers().Having("COUNT(*) >= ?", leaderboardMinAnswers).Scan(&overall).Error; err != nil {
+			return err
+		}
+		for _, r := range overall {
+			acc := answerCount{Total: r.Total, Correct: r.Correct}.Accuracy()
+			entries = append(entries, LeaderboardEntry{Board: BoardAccuracy, UserID: r.UserID, Value: *acc, Volume: r.Total, ComputedAt: now})
+		}
+
+		var best []row
+		if err := tx.Model(&Exam{}).
+			Select("user_id as user_id, COUNT(*) as total, MAX(score_percent) as best").
+			Where("user_id IN (?) AND type = ? AND finished_at IS NOT NULL AND score_percent IS NOT NULL", leaderboardUsers(tx), "exam").
+			Group("user_id").Scan(&best).Error; err != nil {
+			return err
+		}
+		for _, r := range best {
+			entries = append(entries, LeaderboardEntry{Board: BoardBestExam, UserID: r.UserID, Value: r.Best, Volume: r.Total, ComputedAt: now})
+		}
+
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&entries, 200).Error
+	})
+}
+
+// StartLeaderboardRefresh uruchamia w tle okresowe przeliczanie rankingów.
+// interval <= 0 wyłącza job.
+func StartLeaderboardRefresh(db *gorm.DB, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := RefreshLeaderboards(db, time.Now()); err != nil {
+				log.Printf("leaderboards: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+type LeaderboardRow struct {
+	Rank        int     `json:"rank"`
+	DisplayName string  `json:"displayName"`
+	Value       float64 `json:"value"`
+	Volume      int64   `json:"volume"`
+	IsMe        bool    `json:"isMe,omitempty"`
+}
+
+// rankEntries nadaje miejsca (ex aequo: 1, 2, 2, 4); wejście posortowane malejąco po Value.
+func rankEntries(rows []LeaderboardRow) {
+	for i := range rows {
+		if i > 0 && rows[i].Value == rows[i-1].Value {
+			rows[i].Rank = rows[i-1].Rank
+		} else {
+			rows[i].Rank = i + 1
+		}
+	}
+}
+
+// GET /api/v1/leaderboards/:board?group=ID&limit=20
+// Zgoda/nazwa sprawdzane przy odczycie, więc wypisanie działa od razu; nowe wartości po przeliczeniu.
+func Leaderboard(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := currentUserID(c)
+		board := c.Param("board")
+		if !containsString(leaderboardBoards, board) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown leaderboard"})
+			return
+		}
+		limit, _ := parsePagination(c)
+
+		tx := db.Table("leaderboard_entries l").
+			Select("u.display_name as display_name, l.value as value, l.volume as volume, l.user_id as user_id, l.computed_at as computed_at").
+			Joins("JOIN users u ON u.id = l.user_id").
+			Where("l.board = ? AND u.leaderboard_opt_in = ? AND u.display_name IS NOT NULL", board, true).
+			Order("l.value DESC, l.volume DESC, u.display_name")
+		scope := "global"
+		if g := c.Query("group"); g != "" {
+			gid, err := strconv.ParseUint(g, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "bad group id"})
+				return
+			}
+			var n int64
+			db.Model(&GroupMember{}).Where("group_id = ? AND user_id = ?", gid, uid).Count(&n)
+			if n == 0 {
+				c.JSON(http.StatusForbidden, gin.H{"error": "not a group member"})
+				return
+			}
+			tx = tx.Where("l.user_id IN (?)", db.Model(&GroupMember{}).Select("user_id").Where("group_id = ?", gid))
+			scope = "group"
+		}
+
+		var rows []struct {
+			DisplayName string
+			Value       float64
+			Volume      int64
+			UserID      uint
+			ComputedAt  time.Time
+		}
+		if err := tx.Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		all := make([]LeaderboardRow, 0, len(rows))
+		var computedAt *time.Time
+		for _, r := range rows {
+			all = append(all, LeaderboardRow{DisplayName: r.DisplayName, Value: r.Value, Volume: r.Volume, IsMe: uid != 0 && r.UserID == uid})
+			if computedAt == nil {
+				t := r.ComputedAt
+				computedAt = &t
+			}
+		}
+		rankEntries(all)
+
+		// własne miejsce także spoza pierwszej strony
+		var me *LeaderboardRow
+		for i := range all {
+			if all[i].IsMe {
+				me = &all[i]
+				break
+			}
+		}
+		items := all
+		if len(items) > limit {
+			items = items[:limit]
+		}
+		resp := gin.H{"board": board, "scope": scope, "total": len(all), "items": items, "computedAt": computedAt, "me": me}
+		if board == BoardAccuracy {
+			resp["minAnswers"] = leaderboardMinAnswers
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRefreshLeaderboards(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC) // środa; tydzień od 3 marca
+	name := func(s string) *string { return &s }
+	users := []User{
+		{PublicID: "ann", DisplayName: name("Ann"), LeaderboardOptIn: true},
+		{PublicID: "bob", DisplayName: name("Bob"), LeaderboardOptIn: true},
+		{PublicID: "eve", DisplayName: name("Eve")}, // bez zgody
+	}
+	for i := range users {
+		db.Create(&users[i])
+	}
+	// answer dodaje n odpowiedzi (correct z nich poprawnych) w egzaminie usera
+	answer := func(u User, examID string, n, correct int, at time.Time) {
+		db.Create(&Exam{ID: examID, UserID: &u.ID, Type: "exam", StartedAt: at, DurationSeconds: 60})
+		for i := 0; i < n; i++ {
+			db.Create(&Answer{ExamID: examID, QuestionID: fmt.Sprint(i), SelectedRaw: "[]", IsCorrect: i < correct, AnsweredAt: at})
+		}
+	}
+	answer(users[0], "a1", 60, 45, now.AddDate(0, 0, -10)) // poprzedni tydzień
+	answer(users[0], "a2", 5, 5, now.Add(-time.Hour))
+	answer(users[1], "b1", 20, 20, now.Add(-time.Hour)) // za mało na ranking skuteczności
+	answer(users[2], "e1", 100, 100, now.Add(-time.Hour))
+	db.Create(&Exam{ID: "b2", UserID: &users[1].ID, Type: ExamTypeDrill, StartedAt: now, DurationSeconds: 60})
+	db.Create(&Answer{ExamID: "b2", QuestionID: "0", SelectedRaw: "[]", IsCorrect: true, AnsweredAt: now.Add(-time.Hour)})
+	fin, score := now, 80.0
+	db.Model(&Exam{}).Where("id = ?", "b1").Updates(map[string]interface{}{"finished_at": fin, "score_percent": score})
+
+	if err := RefreshLeaderboards(db, now); err != nil {
+		t.Fatal(err)
+	}
+	entries := map[string]map[uint]LeaderboardEntry{}
+	var all []LeaderboardEntry
+	db.Find(&all)
+	for _, e := range all {
+		if entries[e.Board] == nil {
+			entries[e.Board] = map[uint]LeaderboardEntry{}
+		}
+		entries[e.Board][e.UserID] = e
+	}
+	if _, ok := entries[BoardWeeklyAnswers][users[2].ID]; ok {
+		t.Error("user without opt-in materialised")
+	}
+	if e := entries[BoardWeeklyAnswers][users[0].ID]; e.Value != 5 {
+		t.Errorf("weekly answers = %v, want 5 (only this week)", e.Value)
+	}
+	if e, ok := entries[BoardAccuracy][users[0].ID]; !ok || e.Value != 50.0/65*100 || e.Volume != 65 {
+		t.Errorf("accuracy entry = %+v", e)
+	}
+	if e := entries[BoardWeeklyAnswers][users[1].ID]; e.Value != 20 {
+		t.Errorf("weekly answers = %v, want 20 (drills left out)", e.Value)
+	}
+	if _, ok := entries[BoardAccuracy][users[1].ID]; ok {
+		t.Error("accuracy board should require minimum volume")
+	}
+	if e := entries[BoardBestExam][users[1].ID]; e.Value != 80 || len(entries[BoardBestExam]) != 1 {
+		t.Errorf("best exam entries = %+v", entries[BoardBestExam])
+	}
+
+	// ponowne przeliczenie zastępuje wpisy, nie dubluje
+	if err := RefreshLeaderboards(db, now); err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	db.Model(&LeaderboardEntry{}).Count(&n)
+	if n != int64(len(all)) {
+		t.Errorf("entries after refresh = %d, want %d", n, len(all))
+	}
+}
+
+func TestRefreshLeaderboardsCountsEachQuestionOnce(t *testing.T) {
+	db := newTestDB(t)
+	// odpowiedzi zapisane przed idx_answer_exam_question: to samo pytanie wiele razy
+	if err := db.Migrator().DropIndex(&Answer{}, "idx_answer_exam_question"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	name := "Ann"
+	u := User{PublicID: "ann", DisplayName: &name, LeaderboardOptIn: true}
+	db.Create(&u)
+	db.Create(&Exam{ID: "e1", UserID: &u.ID, Type: "exam", StartedAt: now, DurationSeconds: 60})
+	for i := 0; i < 80; i++ {
+		db.Create(&Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: "[]", IsCorrect: i < 79, AnsweredAt: now})
+	}
+	if err := RefreshLeaderboards(db, now); err != nil {
+		t.Fatal(err)
+	}
+	var weekly LeaderboardEntry
+	db.First(&weekly, "board = ? AND user_id = ?", BoardWeeklyAnswers, u.ID)
+	if weekly.Value != 1 {
+		t.Errorf("weekly answers = %v, want 1", weekly.Value)
+	}
+	var n int64
+	db.Model(&LeaderboardEntry{}).Where("board = ?", BoardAccuracy).Count(&n)
+	if n != 0 {
+		t.Error("repeated answers reached the accuracy minimum")
+	}
+}
+
+func TestRankEntries(t *testing.T) {
+	rows := []LeaderboardRow{{Value: 90}, {Value: 80}, {Value: 80}, {Value: 70}}
+	rankEntries(rows)
+	for i, want := range []int{1, 2, 2, 4} {
+		if rows[i].Rank != want {
+			t.Errorf("row %d rank = %d, want %d", i, rows[i].Rank, want)
+		}
+	}
+}
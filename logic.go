@@ -45,20 +45,79 @@ func isCorrectAllOrNothing(selected, correct []string) bool {
         return true
 }
 
+var errNoAnswers = errors.New("no answers")
+
+// computeExamScore liczy wynik jako sumę punktów (wg strategii zapisanej w odpowiedziach)
+// przez liczbę pytań egzaminu — pominięte pytanie liczy się jako 0 pkt, więc przy ujemnym
+// punktowaniu nie da się uniknąć kary brakiem odpowiedzi. Liczy się najnowsza odpowiedź
+// na każde pytanie egzaminu; egzaminy bez ExamQuestion (sprzed zapisu kolejności) dzielą
+// przez liczbę pytań z odpowiedzią. correct/wrong to liczba pytań odpowiedzianych
+// dokładnie poprawnie/błędnie.
 func computeExamScore(db *gorm.DB, examID string) (float64, int, int, error) {
 	var answers []Answer
-	if err := db.Where("exam_id = ?", examID).Find(&answers).Error; err != nil {
+	if err := db.Where("exam_id = ?", examID).Order("id").Find(&answers).Error; err != nil {
 		return 0,0,0, err
 	}
-	if len(answers) == 0 {
-		return 0,0,0, errors.New("no answers")
+	var qids []string
+	if err := db.Model(&ExamQuestion{}).Where("exam_id = ?", examID).Pluck("question_id", &qids).Error; err != nil {
+		return 0,0,0, err
 	}
-	total := len(answers)
-	correct := 0
+	inExam := map[string]bool{}
+	for _, id := range qids { inExam[id] = true }
+	latest := map[string]Answer{}
 	for _, a := range answers {
+		if len(inExam) == 0 || inExam[a.QuestionID] {
+			latest[a.QuestionID] = a
+		}
+	}
+	if len(latest) == 0 {
+		return 0,0,0, errNoAnswers
+	}
+	total := len(qids)
+	if total == 0 {
+		total = len(latest)
+	}
+	correct := 0
+	points := 0.0
+	for _, a := range latest {
 		if a.IsCorrect { correct++ }
+		points += answerPoints(a)
 	}
-	return float64(correct) * 100.0 / float64(total), correct, total - correct, nil
+	if points < 0 { points = 0 } // ujemne punktowanie nie schodzi poniżej 0%
+	return points * 100.0 / float64(total), correct, len(latest) - correct, nil
+}
+
+// dedupeExamAnswers zostawia jedną (najnowszą) odpowiedź na pytanie egzaminu, z czasem
+// zsumowanym po wszystkich wizytach — porządkuje bazy sprzed idx_answer_exam_question.
+func dedupeExamAnswers(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&Answer{}) {
+		return nil
+	}
+	type dup struct {
+		ExamID     string
+		QuestionID string
+		Keep       uint
+		Ms         *int64
+	}
+	var dups []dup
+	if err := db.Model(&Answer{}).
+		Select("exam_id as exam_id, question_id as question_id, MAX(id) as keep, SUM(time_spent_ms) as ms").
+		Group("exam_id, question_id").Having("COUNT(*) > 1").
+		Scan(&dups).Error; err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, d := range dups {
+			if err := tx.Model(&Answer{}).Where("id = ?", d.Keep).Update("time_spent_ms", d.Ms).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("exam_id = ? AND question_id = ? AND id <> ?", d.ExamID, d.QuestionID, d.Keep).
+				Delete(&Answer{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func computeCorrectKeys(db *gorm.DB, qid string) ([]string, error) {
@@ -66,16 +125,70 @@ func computeCorrectKeys(db *gorm.DB, qid string) ([]string, error) {
 	if err := db.Where("question_id = ?", qid).Find(&opts).Error; err != nil {
 		return nil, err
 	}
-	var keys []string
+	return correctKeysOf(opts), nil
+}
+
+func jsonArray(v []string) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// SelectionError opisuje odrzucony wybór opcji (422 z LearnAnswer/ExamAnswer).
+type SelectionError struct {
+	SelectCount   int      `json:"selectCount"`             // ile opcji można wybrać (= liczba poprawnych)
+	UnknownKeys   []string `json:"unknownKeys,omitempty"`   // klucze spoza opcji pytania
+	DuplicateKeys []string `json:"duplicateKeys,omitempty"` // klucze podane więcej niż raz
+	SurplusKeys   []string `json:"surplusKeys,omitempty"`   // klucze ponad selectCount (od końca wyboru)
+}
+
+// selectCount to oczekiwana liczba odpowiedzi, wyprowadzona z poprawnych opcji.
+func selectCount(opts []Option) int {
+	n := 0
 	for _, o := range opts {
 		if o.IsCorrect {
-			keys = append(keys, o.OptionKey)
+			n++
 		}
 	}
-	return keys, nil
+	return n
 }
 
-func jsonArray(v []string) string {
-	b, _ := json.Marshal(v)
-	return string(b)
+// validateSelection sprawdza wybór (klucze już lowercase) względem opcji pytania;
+// nil = poprawny. Mniej kluczy niż selectCount (albo brak) jest dozwolone.
+func validateSelection(selected []string, opts []Option) *SelectionError {
+	e := SelectionError{SelectCount: selectCount(opts)}
+	known := map[string]bool{}
+	for _, o := range opts {
+		known[o.OptionKey] = true
+	}
+	seen := map[string]bool{}
+	var valid []string
+	for _, k := range selected {
+		switch {
+		case !known[k]:
+			e.UnknownKeys = append(e.UnknownKeys, k)
+		case seen[k]:
+			e.DuplicateKeys = append(e.DuplicateKeys, k)
+		default:
+			seen[k] = true
+			valid = append(valid, k)
+		}
+	}
+	if len(valid) > e.SelectCount {
+		e.SurplusKeys = valid[e.SelectCount:]
+	}
+	if len(e.UnknownKeys) == 0 && len(e.DuplicateKeys) == 0 && len(e.SurplusKeys) == 0 {
+		return nil
+	}
+	return &e
+}
+
+// correctKeysOf zwraca klucze poprawnych opcji (jak computeCorrectKeys, bez zapytania).
+func correctKeysOf(opts []Option) []string {
+	var keys []string
+	for _, o := range opts {
+		if o.IsCorrect {
+			keys = append(keys, o.OptionKey)
+		}
+	}
+	return keys
 }
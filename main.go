@@ -3,6 +3,9 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,39 +20,127 @@ func main() {
 		log.Fatalf("migrate: %v", err)
 	}
 
-	// 2) Seed (jeśli pusto)
-	if isEmpty, _ := IsQuestionTableEmpty(db); isEmpty {
-		path := "data/questions.json"
-		if _, err := os.Stat(path); err == nil {
-			if err := SeedFromJSON(db, path); err != nil {
-				log.Fatalf("seed: %v", err)
+	// 2) Seed: każdy plik data/*.json to osobny bank (seed tylko do pustych banków)
+	if err := SeedBanks(db, "data"); err != nil {
+		log.Fatalf("seed: %v", err)
+	}
+
+	// Admini: ADMIN_PUBLIC_IDS="uuid1,uuid2" (klucze z /me/export-key)
+	if v := os.Getenv("ADMIN_PUBLIC_IDS"); v != "" {
+		var ids []string
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
 			}
-			log.Printf("Seeded questions from %s", path)
-		} else {
-			log.Printf("No seed file at %s; running with empty DB", path)
+		}
+		if err := PromoteAdmins(db, ids); err != nil {
+			log.Fatalf("promote admins: %v", err)
+		}
+	}
+
+	// 3) Cleanup anonimowych userów bez aktywności (ANON_USER_MAX_AGE_DAYS, 0 = wyłączone)
+	maxAgeDays := 30
+	if v := os.Getenv("ANON_USER_MAX_AGE_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxAgeDays = n
+		}
+	}
+	StartUserCleanup(db, time.Duration(maxAgeDays)*24*time.Hour, 24*time.Hour)
+
+	// Rankingi przeliczane co LEADERBOARD_REFRESH_MINUTES (domyślnie 15, 0 = wyłączone)
+	refreshMinutes := 15
+	if v := os.Getenv("LEADERBOARD_REFRESH_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			refreshMinutes = n
+		}
+	}
+	StartLeaderboardRefresh(db, time.Duration(refreshMinutes)*time.Minute)
+
+	// Kalibracja trudności pytań (Rasch) co CALIBRATION_INTERVAL_HOURS (domyślnie 24, 0 = wyłączone)
+	calibrationHours := 24
+	if v := os.Getenv("CALIBRATION_INTERVAL_HOURS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			calibrationHours = n
 		}
 	}
+	StartDifficultyCalibration(db, time.Duration(calibrationHours)*time.Hour)
 
-	// 3) Router
+	// 4) Router
 	r := gin.Default()
 
+	// user tylko odczytywany z cookie; tworzony leniwie przez EnsureUser na endpointach zapisu
+	r.Use(LoadUser(db))
 	// secureCookies: w dev zwykle false; w prod za HTTPS → true
-	r.Use(EnsureUser(db, false))
+	ensureUser := EnsureUser(db, false)
 
 	api := r.Group("/api/v1")
 	{
+		api.GET("/banks", ListBanks(db))                          // certyfikacje / banki pytań z domyślnymi ustawieniami
 		api.GET("/questions", ListQuestions(db))                  // tryb nauki: pobierz pytania (paginacja/tagi w kolejnych iteracjach)
 		api.POST("/learn/answer", LearnAnswer(db))                // tryb nauki: odpowiedź -> od razu feedback + wyjaśnienia
-		api.POST("/exams", StartExam(db))                         // start egzaminu (80 pytań domyślnie)
+		api.POST("/questions/:id/report", ensureUser, ReportQuestion(db)) // zgłoszenie błędu w pytaniu
+		api.GET("/questions/:id/comments", ListComments(db))      // dyskusja pod pytaniem (wątki)
+		api.POST("/questions/:id/comments", ensureUser, CreateComment(db))
+		api.PUT("/comments/:id", UpdateComment(db))               // tylko własne
+		api.DELETE("/comments/:id", DeleteComment(db))            // własne albo admin
+		api.POST("/comments/:id/upvote", VoteComment(db, true))
+		api.DELETE("/comments/:id/upvote", VoteComment(db, false))
+		api.POST("/comments/:id/accept", AcceptComment(db))       // autor wątku albo admin
+		api.POST("/proposals", ensureUser, RequireRole(RoleAuthor, RoleReviewer), CreateProposal(db)) // nowe pytanie od społeczności (draft)
+		api.GET("/proposals", ListProposals(db))                  // własne; reviewer: wszystkie, ?status=submitted
+		api.GET("/proposals/:id", GetProposal(db))
+		api.PUT("/proposals/:id", RequireRole(RoleAuthor, RoleReviewer), UpdateProposal(db)) // tylko draft/rejected, wraca do draft
+		api.POST("/proposals/:id/submit", RequireRole(RoleAuthor, RoleReviewer), SubmitProposal(db))
+		api.POST("/proposals/:id/review", RequireRole(RoleReviewer), ReviewProposal(db)) // approve → żywe pytanie
+		api.POST("/exams", ensureUser, StartExam(db))             // start egzaminu (80 pytań domyślnie)
+		api.POST("/exams/:id/questions/:qid/view", ViewExamQuestion(db)) // start pomiaru czasu na pytanie
+		api.GET("/exams/:id/next", NextExamQuestion(db))          // tryb sekwencyjny: bieżące/kolejne pytanie
+		api.GET("/exams/:id/questions/:qid", GetExamQuestion(db)) // tryb sekwencyjny: powrót do pytania (allowBack)
 		api.POST("/exams/:id/answer", ExamAnswer(db))             // zapis odpowiedzi, bez ujawniania poprawności
 		api.POST("/exams/:id/finish", FinishExam(db))             // wynik + raport
+		api.POST("/exams/:id/pause", PauseExam(db))               // zatrzymanie zegara (do limitu maxPauseSec)
+		api.POST("/exams/:id/resume", ResumeExam(db))             // wznowienie zegara
+		api.POST("/exams/:id/abandon", AbandonExam(db))           // porzucenie; znika ze statystyk
+		api.DELETE("/exams/:id", DeleteExam(db))                  // tylko nieukończone
+		api.POST("/exams/:id/retake", RetakeExam(db))             // nowy egzamin z pytań ukończonego: same|shuffled|wrong
 		api.GET("/me", GetMe(db))
-    	api.PUT("/me", UpdateMe(db))
+		api.PUT("/me", ensureUser, UpdateMe(db))
 		api.GET("/me/export-key", ExportKey(db))
 		api.POST("/me/restore", RestoreAccount(db, false)) 		  // prod: true
+		api.POST("/me/merge", ensureUser, MergeAccount(db))       // przenieś egzaminy z innego konta do bieżącego
+		api.GET("/me/export", ExportMyData(db))                   // archiwum ZIP (JSON + CSV) z danymi usera
+		api.POST("/me/deletion-token", RequestDeletion(db))       // krok 1 usunięcia konta
+		api.DELETE("/me", DeleteMe(db, false))                    // krok 2: ?token=...&anonymize=true; prod: true
 		api.GET("/exams", ListMyExams(db))
 		api.GET("/exams/:id", GetMyExam(db))
+		api.GET("/exams/:id/compare/:otherId", CompareExams(db))  // ten sam Seed: pytanie po pytaniu
 		api.GET("/stats", Stats(db))
+		api.GET("/stats/activity", Activity(db))                  // kubełki dzień/tydzień, serie, heatmapa; ?tz=
+		api.GET("/stats/readiness", Readiness(db))                // szansa zdania + domeny obniżające wynik
+		api.GET("/stats/exams/trend", ExamScoreTrend(db))         // wyniki w czasie, średnia krocząca, per tag
+		api.GET("/leaderboards/:board", Leaderboard(db))          // weekly_answers | accuracy | best_exam; ?group=
+
+		api.POST("/groups", ensureUser, CreateGroup(db))          // nowa kohorta, twórca = trener
+		api.GET("/groups", ListMyGroups(db))
+		api.POST("/groups/join", ensureUser, JoinGroup(db))       // dołącz kodem zaproszenia
+		api.POST("/groups/:id/leave", LeaveGroup(db))
+		api.GET("/groups/:id/members", GroupProgress(db))         // trener: postęp członków
+		api.PUT("/groups/:id/members/:userId/role", SetMemberRole(db))
+		api.GET("/groups/:id/members/:userId/exams", GroupMemberExams(db))
+		api.GET("/groups/:id/heatmap", GroupHeatmap(db))          // trener: słabe obszary kohorty per tag
+		api.POST("/groups/:id/assignments", CreateAssignment(db)) // trener: zadany egzamin z oknem czasowym
+		api.GET("/groups/:id/assignments", ListAssignments(db))
+		api.GET("/groups/:id/assignments/:aid/results", AssignmentResults(db))
+	}
+
+	admin := api.Group("/admin", RequireAdmin())
+	{
+		admin.GET("/item-analysis", ItemAnalysis(db)) // p-value, dyskryminacja, dystraktory; ?format=csv
+		admin.GET("/reports", ListReports(db))        // kolejka moderacji zgłoszeń per pytanie
+		admin.PATCH("/reports/:id", UpdateReport(db)) // open → accepted/rejected/fixed
+		admin.PUT("/users/:publicId/role", SetUserRole(db)) // author/reviewer/admin
+		admin.POST("/calibrate", RunCalibration(db))  // kalibracja trudności pytań na żądanie
+		admin.GET("/metrics/users", UserMetrics(db))  // liczba userów: anonimowi / nazwani
 	}
 
 	port := os.Getenv("PORT")
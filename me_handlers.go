@@ -1,21 +1,31 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type MeResponse struct {
-	PublicID    string  `json:"publicId"`
-	DisplayName *string `json:"displayName,omitempty"`
-	Email       *string `json:"email,omitempty"` // na przyszłość
+	PublicID         string  `json:"publicId"`
+	DisplayName      *string `json:"displayName,omitempty"`
+	Email            *string `json:"email,omitempty"` // na przyszłość
+	Timezone         *string `json:"timezone,omitempty"`
+	LeaderboardOptIn bool    `json:"leaderboardOptIn"`
+}
+
+func toMeResponse(u User) MeResponse {
+	return MeResponse{PublicID: u.PublicID, DisplayName: u.DisplayName, Email: u.Email, Timezone: u.Timezone, LeaderboardOptIn: u.LeaderboardOptIn}
 }
 
 type MeUpdateReq struct {
-	DisplayName *string `json:"displayName"` // opcjonalne
+	DisplayName      *string `json:"displayName"`      // opcjonalne
+	Timezone         *string `json:"timezone"`         // opcjonalne; "" czyści
+	LeaderboardOptIn *bool   `json:"leaderboardOptIn"` // opcjonalne; true wymaga displayName
 	// Email *string `json:"email"` // sugeruję dodać dopiero z weryfikacją
 }
 
@@ -23,6 +33,11 @@ type RestoreReq struct {
 	PublicID string `json:"publicId"`
 }
 
+var errSameAccount = errors.New("same account")
+
+// roleRank porządkuje role przy scalaniu kont: zostaje wyższa.
+var roleRank = map[string]int{"": 0, RoleAuthor: 1, RoleReviewer: 2, RoleAdmin: 3}
+
 // GET /api/v1/me
 func GetMe(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -37,11 +52,7 @@ func GetMe(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
 			return
 		}
-		c.JSON(http.StatusOK, MeResponse{
-			PublicID:    u.PublicID,
-			DisplayName: u.DisplayName,
-			Email:       u.Email,
-		})
+		c.JSON(http.StatusOK, toMeResponse(u))
 	}
 }
 
@@ -76,6 +87,26 @@ func UpdateMe(db *gorm.DB) gin.HandlerFunc {
 			u.DisplayName = &name
 		}
 
+		if req.Timezone != nil {
+			tz := strings.TrimSpace(*req.Timezone)
+			if tz == "" {
+				u.Timezone = nil
+			} else if _, err := time.LoadLocation(tz); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "timezone must be an IANA name, e.g. Europe/Warsaw"})
+				return
+			} else {
+				u.Timezone = &tz
+			}
+		}
+
+		if req.LeaderboardOptIn != nil {
+			if *req.LeaderboardOptIn && u.DisplayName == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "set displayName before joining leaderboards"})
+				return
+			}
+			u.LeaderboardOptIn = *req.LeaderboardOptIn
+		}
+
 		// (Email polecam dodać dopiero z flow weryfikacji/magic linkiem)
 
 		if err := db.Save(&u).Error; err != nil {
@@ -83,11 +114,7 @@ func UpdateMe(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, MeResponse{
-			PublicID:    u.PublicID,
-			DisplayName: u.DisplayName,
-			Email:       u.Email,
-		})
+		c.JSON(http.StatusOK, toMeResponse(u))
 	}
 }
 
@@ -126,4 +153,174 @@ func RestoreAccount(db *gorm.DB, secureCookies bool) gin.HandlerFunc {
 		})
 		c.JSON(200, gin.H{"status": "restored"})
 	}
-}
\ No newline at end of file
+}
+type MergeReq struct {
+	PublicID string `json:"publicId"` // klucz z export-key drugiego konta
+}
+
+// userOwnedModels to tabele z kolumną user_id, przenoszone przy scalaniu kont.
+// Nowe dane usera (np. zakładki) dopisujemy tutaj.
+var userOwnedModels = []interface{}{
+	&Exam{},
+	&QuestionReport{},
+	&Comment{},
+}
+
+// mergeUsers przenosi dane source → target, rozwiązuje konflikty profilu
+// (wygrywa wartość z target, puste pola uzupełniamy z source) i usuwa source.
+func mergeUsers(tx *gorm.DB, target, source *User) (int, error) {
+	var examsMoved int64
+	if err := tx.Model(&Exam{}).Where("user_id = ?", source.ID).Count(&examsMoved).Error; err != nil {
+		return 0, err
+	}
+	// to samo zadanie na obu kontach: jedno podejście na usera, drugie zostaje zwykłym egzaminem
+	if err := tx.Model(&Exam{}).
+		Where("user_id = ? AND assignment_id IN (?)", source.ID,
+			tx.Model(&Exam{}).Select("assignment_id").Where("user_id = ? AND assignment_id IS NOT NULL", target.ID)).
+		Update("assignment_id", nil).Error; err != nil {
+		return 0, err
+	}
+	for _, m := range userOwnedModels {
+		if err := tx.Model(m).Where("user_id = ?", source.ID).Update("user_id", target.ID).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	// propozycje pytań i atrybucja opublikowanych pytań (kolumna author_id)
+	if err := tx.Model(&QuestionProposal{}).Where("author_id = ?", source.ID).Update("author_id", target.ID).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&Question{}).Where("author_id = ?", source.ID).Update("author_id", target.ID).Error; err != nil {
+		return 0, err
+	}
+
+	// grupy i zadania założone przez source
+	if err := tx.Model(&Group{}).Where("owner_id = ?", source.ID).Update("owner_id", target.ID).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&Assignment{}).Where("created_by = ?", source.ID).Update("created_by", target.ID).Error; err != nil {
+		return 0, err
+	}
+
+	// członkostwa w grupach: przenieś tylko te, w których target jeszcze nie jest;
+	// we wspólnych grupach target dostaje rolę trenera, jeśli miał ją source
+	if err := tx.Model(&GroupMember{}).
+		Where("user_id = ? AND group_id IN (?)", target.ID,
+			tx.Model(&GroupMember{}).Select("group_id").Where("user_id = ? AND role = ?", source.ID, GroupRoleTrainer)).
+		Update("role", GroupRoleTrainer).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&GroupMember{}).
+		Where("user_id = ? AND group_id NOT IN (?)", source.ID,
+			tx.Model(&GroupMember{}).Select("group_id").Where("user_id = ?", target.ID)).
+		Update("user_id", target.ID).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("user_id = ?", source.ID).Delete(&GroupMember{}).Error; err != nil {
+		return 0, err
+	}
+
+	// głosy na komentarze: przy podwójnym głosie zostaje jeden, licznik w dół
+	dupVotes := tx.Model(&CommentVote{}).Select("comment_id").Where("user_id = ?", target.ID)
+	if err := tx.Model(&Comment{}).
+		Where("id IN (?) AND id IN (?)", dupVotes, tx.Model(&CommentVote{}).Select("comment_id").Where("user_id = ?", source.ID)).
+		Update("upvotes", gorm.Expr("upvotes - 1")).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&CommentVote{}).
+		Where("user_id = ? AND comment_id NOT IN (?)", source.ID, dupVotes).
+		Update("user_id", target.ID).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("user_id = ?", source.ID).Delete(&CommentVote{}).Error; err != nil {
+		return 0, err
+	}
+	// rankingi: wpisy source znikają, target przeliczy się przy następnym odświeżeniu
+	if err := tx.Where("user_id = ?", source.ID).Delete(&LeaderboardEntry{}).Error; err != nil {
+		return 0, err
+	}
+	// token usunięcia source nie może dalej działać
+	if err := tx.Where("user_id = ?", source.ID).Delete(&DeletionToken{}).Error; err != nil {
+		return 0, err
+	}
+
+	if target.Timezone == nil && source.Timezone != nil {
+		target.Timezone = source.Timezone
+	}
+	if target.DisplayName == nil && source.DisplayName != nil {
+		target.DisplayName = source.DisplayName
+	}
+	if target.Email == nil && source.Email != nil {
+		target.Email = source.Email
+	}
+	if roleRank[source.Role] > roleRank[target.Role] {
+		target.Role = source.Role // wyższe uprawnienia z dwóch kont
+	}
+	// usuń source przed zapisem target — email ma uniqueIndex
+	if err := tx.Delete(source).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Save(target).Error; err != nil {
+		return 0, err
+	}
+
+	audit := AccountMerge{
+		TargetUserID:   target.ID,
+		SourceUserID:   source.ID,
+		SourcePublicID: source.PublicID,
+		ExamsMoved:     int(examsMoved),
+	}
+	if err := tx.Create(&audit).Error; err != nil {
+		return 0, err
+	}
+	return int(examsMoved), nil
+}
+
+// POST /api/v1/me/merge
+func MergeAccount(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		var req MergeReq
+		if err := c.BindJSON(&req); err != nil || strings.TrimSpace(req.PublicID) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "publicId required"})
+			return
+		}
+
+		var target, source User
+		var moved int
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.First(&target, uid).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&source, "public_id = ?", strings.TrimSpace(req.PublicID)).Error; err != nil {
+				return err
+			}
+			if source.ID == target.ID {
+				return errSameAccount
+			}
+			var err error
+			moved, err = mergeUsers(tx, &target, &source)
+			return err
+		})
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		case errors.Is(err, errSameAccount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cannot merge account with itself"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"user":       toMeResponse(target),
+			"examsMoved": moved,
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeUsers(t *testing.T) {
+	db := newTestDB(t)
+
+	name := "Laptop"
+	email := "phone@example.com"
+	target := User{PublicID: "target", DisplayName: &name}
+	source := User{PublicID: "source", DisplayName: strPtr("Phone"), Email: &email, Role: RoleReviewer}
+	for _, u := range []*User{&target, &source} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	for _, id := range []string{"e1", "e2"} {
+		e := Exam{ID: id, UserID: &source.ID, Type: "exam", DurationSeconds: 60}
+		if err := db.Create(&e).Error; err != nil {
+			t.Fatalf("create exam: %v", err)
+		}
+	}
+
+	// source jest trenerem i właścicielem grupy, w której target jest zwykłym członkiem
+	g := Group{Name: "team", InviteCode: "TEAM", OwnerID: source.ID}
+	db.Create(&g)
+	db.Create(&GroupMember{GroupID: g.ID, UserID: source.ID, Role: GroupRoleTrainer, JoinedAt: time.Now()})
+	db.Create(&GroupMember{GroupID: g.ID, UserID: target.ID, Role: GroupRoleMember, JoinedAt: time.Now()})
+	a := Assignment{GroupID: g.ID, CreatedBy: source.ID, Title: "week 1", OpensAt: time.Now(), ClosesAt: time.Now().Add(time.Hour), DurationSeconds: 60}
+	db.Create(&a)
+	db.Create(&DeletionToken{UserID: source.ID, Token: "tok", ExpiresAt: time.Now().Add(time.Hour)})
+
+	moved, err := mergeUsers(db, &target, &source)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("moved = %d, want 2", moved)
+	}
+
+	var owned int64
+	db.Model(&Exam{}).Where("user_id = ?", target.ID).Count(&owned)
+	if owned != 2 {
+		t.Errorf("target owns %d exams, want 2", owned)
+	}
+	var got User
+	if err := db.First(&got, target.ID).Error; err != nil {
+		t.Fatalf("load target: %v", err)
+	}
+	if got.DisplayName == nil || *got.DisplayName != "Laptop" {
+		t.Errorf("displayName = %v, want target's value kept", got.DisplayName)
+	}
+	if got.Email == nil || *got.Email != email {
+		t.Errorf("email = %v, want taken from source", got.Email)
+	}
+	if err := db.First(&User{}, source.ID).Error; err == nil {
+		t.Errorf("source user still exists")
+	}
+	if got.Role != RoleReviewer {
+		t.Errorf("role = %q, want the source's reviewer role", got.Role)
+	}
+	var group Group
+	db.First(&group, g.ID)
+	var membership GroupMember
+	db.First(&membership, "group_id = ? AND user_id = ?", g.ID, target.ID)
+	if group.OwnerID != target.ID || membership.Role != GroupRoleTrainer {
+		t.Errorf("group owner %d, target role %q; want group ownership and trainer role moved", group.OwnerID, membership.Role)
+	}
+	var assignment Assignment
+	db.First(&assignment, a.ID)
+	if assignment.CreatedBy != target.ID {
+		t.Errorf("assignment created by %d, want target", assignment.CreatedBy)
+	}
+	var tokens int64
+	db.Model(&DeletionToken{}).Where("token = ?", "tok").Count(&tokens)
+	if tokens != 0 {
+		t.Error("source deletion token still active")
+	}
+	var audits int64
+	db.Model(&AccountMerge{}).Where("target_user_id = ? AND source_user_id = ?", target.ID, source.ID).Count(&audits)
+	if audits != 1 {
+		t.Errorf("audit records = %d, want 1", audits)
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestMergeUsersKeepsOneAssignmentAttempt(t *testing.T) {
+	db := newTestDB(t)
+	target, source := User{PublicID: "target"}, User{PublicID: "source"}
+	db.Create(&target)
+	db.Create(&source)
+	aid := uint(7)
+	db.Create(&Exam{ID: "t1", UserID: &target.ID, AssignmentID: &aid, Type: "exam", DurationSeconds: 60})
+	db.Create(&Exam{ID: "s1", UserID: &source.ID, AssignmentID: &aid, Type: "exam", DurationSeconds: 60})
+
+	if _, err := mergeUsers(db, &target, &source); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	var attempts int64
+	db.Model(&Exam{}).Where("user_id = ? AND assignment_id = ?", target.ID, aid).Count(&attempts)
+	var moved Exam
+	db.First(&moved, "id = ?", "s1")
+	if attempts != 1 || moved.UserID == nil || *moved.UserID != target.ID || moved.AssignmentID != nil {
+		t.Errorf("attempts = %d, source exam %+v; want it moved as a practice exam", attempts, moved)
+	}
+}
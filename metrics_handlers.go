@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type UserMetricsResponse struct {
+	TotalUsers      int64 `json:"totalUsers"`
+	AnonymousUsers  int64 `json:"anonymousUsers"`  // bez displayName i e-maila
+	IdentifiedUsers int64 `json:"identifiedUsers"` // z displayName lub e-mailem
+	InactiveUsers   int64 `json:"inactiveUsers"`   // anonimowi bez żadnych danych (kandydaci do cleanupu)
+}
+
+// GET /api/v1/admin/metrics/users
+func UserMetrics(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var resp UserMetricsResponse
+		if err := db.Model(&User{}).Count(&resp.TotalUsers).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		if err := anonymousUsers(db).Count(&resp.AnonymousUsers).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		if err := inactiveUsers(db).Count(&resp.InactiveUsers).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		resp.IdentifiedUsers = resp.TotalUsers - resp.AnonymousUsers
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
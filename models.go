@@ -10,25 +10,184 @@ type User struct {
 	PublicID    string    `gorm:"uniqueIndex;size:36;not null"` // UUID widoczny w cookie
 	DisplayName *string
 	Email       *string   `gorm:"uniqueIndex"`
+	Role        string    `gorm:"size:16;not null;default:''"` // "" | "author" | "reviewer" | "admin"
+	Timezone    *string   `gorm:"size:64"` // IANA, np. "Europe/Warsaw"; do statystyk dziennych
+	LeaderboardOptIn bool `gorm:"not null;default:false"`    // zgoda na pokazanie w rankingach (wymaga DisplayName)
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
   }
 
+const (
+	RoleAuthor   = "author"   // może zgłaszać nowe pytania
+	RoleReviewer = "reviewer" // zatwierdza/odrzuca zgłoszone pytania
+	RoleAdmin    = "admin"    // wszystko powyżej + endpointy /admin
+)
+
+// AccountMerge to wpis audytowy scalenia dwóch kont (source → target).
+type AccountMerge struct {
+	ID             uint      `gorm:"primaryKey"`
+	TargetUserID   uint      `gorm:"index;not null"`
+	SourceUserID   uint      `gorm:"not null"`
+	SourcePublicID string    `gorm:"size:36;not null"`
+	ExamsMoved     int       `gorm:"not null"`
+	CreatedAt      time.Time
+}
+
+// DeletionToken potwierdza usunięcie konta (DELETE /me), ważny krótko.
+type DeletionToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"uniqueIndex;not null"`
+	Token     string    `gorm:"size:36;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+// --- Grupy (kohorty) ---
+
+const (
+	GroupRoleTrainer = "trainer"
+	GroupRoleMember  = "member"
+)
+
+type Group struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Name       string    `gorm:"not null;size:80" json:"name"`
+	InviteCode string    `gorm:"uniqueIndex;size:16;not null" json:"inviteCode"`
+	OwnerID    uint      `gorm:"index;not null" json:"-"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type GroupMember struct {
+	ID       uint      `gorm:"primaryKey"`
+	GroupID  uint      `gorm:"uniqueIndex:idx_group_user;not null"`
+	UserID   uint      `gorm:"uniqueIndex:idx_group_user;index;not null"`
+	Role     string    `gorm:"size:16;not null"` // "trainer" | "member"
+	JoinedAt time.Time `gorm:"not null"`
+}
+
+// Assignment to egzamin zadany grupie przez trenera: stały zestaw pytań
+// i okno czasowe, w którym członkowie mogą go rozpocząć.
+type Assignment struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	GroupID         uint      `gorm:"index;not null" json:"groupId"`
+	CreatedBy       uint      `gorm:"not null" json:"-"`
+	Title           string    `gorm:"not null;size:120" json:"title"`
+	OpensAt         time.Time `gorm:"not null" json:"opensAt"`
+	ClosesAt        time.Time `gorm:"not null" json:"closesAt"`
+	DurationSeconds int       `gorm:"not null" json:"durationSec"`
+	Seed            *int64    `json:"seed,omitempty"`
+	BankID          string    `gorm:"size:32" json:"bank"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+type AssignmentQuestion struct {
+	ID           uint   `gorm:"primaryKey"`
+	AssignmentID uint   `gorm:"index;not null"`
+	QuestionID   string `gorm:"not null"`
+	Position     int    `gorm:"not null"` // 1..N
+}
+
+// --- Rankingi ---
+
+// LeaderboardEntry to zmaterializowana wartość jednego usera w jednym rankingu,
+// przeliczana okresowo (RefreshLeaderboards) zamiast skanować answers przy każdym GET.
+type LeaderboardEntry struct {
+	ID         uint      `gorm:"primaryKey"`
+	Board      string    `gorm:"uniqueIndex:idx_board_user;size:16;not null"` // weekly_answers | accuracy | best_exam
+	UserID     uint      `gorm:"uniqueIndex:idx_board_user;index;not null"`
+	Value      float64   `gorm:"not null"` // liczba odpowiedzi / skuteczność % / najlepszy wynik %
+	Volume     int64     `gorm:"not null"` // odpowiedzi albo ukończone egzaminy, na których oparto Value
+	ComputedAt time.Time `gorm:"not null"`
+}
+
 // --- Pytania ---
 
+// QuestionBank to zestaw pytań pod jedną certyfikację (np. Commerce developer).
+// Ładowany z pliku w data/; plik jest źródłem nazwy i domyślnych ustawień egzaminu.
+type QuestionBank struct {
+	ID                   string    `gorm:"primaryKey;size:32" json:"id"` // slug, prefiks ID pytań ("commerce-dev:001")
+	Name                 string    `gorm:"not null" json:"name"`
+	Description          string    `json:"description,omitempty"`
+	IsDefault            bool      `gorm:"not null;default:false" json:"isDefault"` // bank, gdy klient nie poda ?bank
+	DefaultQuestionCount int       `gorm:"not null" json:"defaultQuestionCount"`
+	DefaultDurationSec   int       `gorm:"not null" json:"defaultDurationSec"`
+	PassPercent          float64   `gorm:"not null" json:"passPercent"`
+	SourceFile           string    `json:"-"`
+	CreatedAt            time.Time `json:"-"`
+	UpdatedAt            time.Time `json:"-"`
+}
+
 type Question struct {
-	ID          string    `gorm:"primaryKey;size:64" json:"id"`
+	ID          string    `gorm:"primaryKey;size:64" json:"id"` // "<bank>:<id z pliku>"
+	BankID      string    `gorm:"index;size:32" json:"bank"`
 	TextEN      string    `gorm:"not null" json:"questionText"`
 	TextPL      *string   `json:"questionTextPl,omitempty"`
 	MultiSelect bool      `gorm:"not null" json:"multiSelect"`
-	Difficulty  *int      `json:"difficulty,omitempty"`
+	Difficulty  *int      `json:"difficulty,omitempty"` // 1..5 z kalibracji (CalibrateDifficulty)
+	DifficultyLogit *float64 `json:"difficultyLogit,omitempty"` // trudność Rascha w logitach; nil = nieskalibrowane
+	CalibrationN    int      `gorm:"not null;default:0" json:"-"` // liczba odpowiedzi użytych w kalibracji
 	Tags        *string   `json:"tags,omitempty"` // CSV albo JSON (na razie prosty string)
 	Version     int       `gorm:"not null;default:1" json:"version"`
+	AuthorID    *uint     `gorm:"index" json:"-"` // autor pytania zgłoszonego przez społeczność
 	Options     []Option  `json:"options"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
 
+// QuestionReport to zgłoszenie błędu w pytaniu od uczącego się (kolejka moderacji).
+type QuestionReport struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	QuestionID     string     `gorm:"index;not null;size:64" json:"questionId"`
+	UserID         uint       `gorm:"index;not null" json:"-"`
+	Category       string     `gorm:"size:32;not null" json:"category"`
+	Comment        string     `json:"comment"`
+	Status         string     `gorm:"size:16;not null;index" json:"status"` // open | accepted | rejected | fixed
+	ResolutionNote string     `json:"resolutionNote,omitempty"`
+	FixedInVersion *int       `json:"fixedInVersion,omitempty"` // Question.Version z poprawką
+	ResolvedBy     *uint      `json:"-"`
+	ResolvedAt     *time.Time `json:"resolvedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// Comment to wpis w dyskusji pod pytaniem; ParentID != nil = odpowiedź w wątku.
+type Comment struct {
+	ID         uint       `gorm:"primaryKey"`
+	QuestionID string     `gorm:"index;not null;size:64"`
+	UserID     uint       `gorm:"index;not null"`
+	ParentID   *uint      `gorm:"index"`
+	Body       string     `gorm:"not null"` // markdown po sanityzacji
+	Upvotes    int        `gorm:"not null;default:0"`
+	Accepted   bool       `gorm:"not null;default:false"`
+	Deleted    bool       `gorm:"not null;default:false"` // soft delete, żeby nie rozbijać wątków
+	EditedAt   *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type CommentVote struct {
+	ID        uint `gorm:"primaryKey"`
+	CommentID uint `gorm:"uniqueIndex:idx_comment_user;not null"`
+	UserID    uint `gorm:"uniqueIndex:idx_comment_user;index;not null"`
+	CreatedAt time.Time
+}
+
+// QuestionProposal to pytanie zaproponowane przez autora (w formacie QInput),
+// przechodzące przez review: draft → submitted → approved | rejected.
+type QuestionProposal struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	AuthorID            uint       `gorm:"index;not null" json:"-"`
+	BankID              string     `gorm:"size:32;not null" json:"bank"`
+	Payload             string     `gorm:"not null" json:"-"` // JSON QInput
+	Status              string     `gorm:"size:16;not null;index" json:"status"`
+	ReviewerID          *uint      `json:"-"`
+	ReviewComment       string     `json:"reviewComment,omitempty"`
+	ReviewedAt          *time.Time `json:"reviewedAt,omitempty"`
+	PublishedQuestionID *string    `gorm:"size:64" json:"publishedQuestionId,omitempty"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
 type Option struct {
 	ID         uint      `gorm:"primaryKey"`
 	QuestionID string    `gorm:"index;not null"`
@@ -55,29 +214,57 @@ type Explanation struct {
 
 type Exam struct {
 	ID              string          `gorm:"primaryKey;size:36" json:"id"`
-	UserID          *uint      		`gorm:"index" json:"-"`
-	Type            string          `gorm:"not null;size:16" json:"type"` // "exam" | "learn"
+	UserID          *uint      		`gorm:"index;uniqueIndex:idx_exam_assignment_user,priority:2" json:"-"`
+	Type            string          `gorm:"not null;size:16" json:"type"` // "exam" | "adaptive" | "drill" | "learn"
 	StartedAt       time.Time       `gorm:"not null"`
 	FinishedAt      *time.Time
+	Status          string          `gorm:"not null;size:16;default:in_progress;index" json:"status"` // in_progress | finished | expired | abandoned
 	DurationSeconds int             `gorm:"not null"` // np. 10800 (3h)
 	ScorePercent    *float64
 	Seed            *int64
+	AssignmentID    *uint           `gorm:"index;uniqueIndex:idx_exam_assignment_user,priority:1" json:"assignmentId,omitempty"` // egzamin zadany przez trenera; jedno podejście na usera
+	BankID          string          `gorm:"index;size:32" json:"bank"`
+	PassPercent     float64         `json:"-"` // próg banku w chwili startu; 0 = passThreshold
+	Scoring         string          `gorm:"size:16;not null;default:''" json:"scoring"` // strategia punktacji; "" = all_or_nothing
+	TargetSE        float64         `json:"-"` // adaptive: koniec po osiągnięciu tego błędu standardowego
+	MaxQuestions    int             `json:"-"` // adaptive: limit pytań
+	AbilityTheta    *float64        `json:"-"` // adaptive: bieżąca ocena umiejętności (logity)
+	AbilitySE       *float64        `json:"-"`
+	Delivery        string          `gorm:"size:16;not null;default:''" json:"delivery"` // "all" | "sequential"; "" = all
+	AllowBack       bool            `gorm:"not null;default:false" json:"allowBack"`     // sequential: powrót do wcześniejszych pytań
+	LockAnswered    bool            `gorm:"not null;default:false" json:"lockAnswered"`  // sequential: odpowiedzi nie można zmienić
+	CurrentPosition int             `gorm:"not null;default:0" json:"-"`                 // sequential: najdalsze wydane pytanie
+	MaxPauseSeconds int             `gorm:"not null;default:0" json:"maxPauseSec"`       // łączny limit pauz; 0 = bez pauzy
+	ParentExamID    *string         `gorm:"index;size:36" json:"parentExamId,omitempty"` // powtórka: egzamin źródłowy
+	RetakeMode      string          `gorm:"size:16" json:"retakeMode,omitempty"`        // same | shuffled | wrong
+	QuestionTimeSec int             `gorm:"not null;default:0" json:"questionTimeSec,omitempty"` // drill: timer na pytanie, 0 = bez
 	Questions       []ExamQuestion
 	Answers         []Answer
 }
 
+// ExamPause to jeden przedział pauzy egzaminu; ResumedAt nil = pauza trwa.
+type ExamPause struct {
+	ID        uint       `gorm:"primaryKey"`
+	ExamID    string     `gorm:"index;not null"`
+	PausedAt  time.Time  `gorm:"not null"`
+	ResumedAt *time.Time
+}
+
 type ExamQuestion struct {
 	ID         uint   `gorm:"primaryKey"`
 	ExamID     string `gorm:"index;not null"`
 	QuestionID string `gorm:"not null"`
 	Position   int    `gorm:"not null"` // 1..N
+	ViewedAt   *time.Time // początek bieżącej wizyty na pytaniu (POST .../view), zerowany przy odpowiedzi
 }
 
 type Answer struct {
 	ID          uint      `gorm:"primaryKey"`
-	ExamID      string    `gorm:"index;not null"`
-	QuestionID  string    `gorm:"not null"`
+	ExamID      string    `gorm:"index;uniqueIndex:idx_answer_exam_question;not null"` // jedna odpowiedź na pytanie egzaminu
+	QuestionID  string    `gorm:"uniqueIndex:idx_answer_exam_question;not null"`
 	SelectedRaw string    `gorm:"not null"` // JSON: ["a","c"]
 	IsCorrect   bool      `gorm:"not null"`
 	AnsweredAt  time.Time `gorm:"not null"`
+	TimeSpentMs *int64    // suma czasu wizyt zakończonych odpowiedzią; nil = brak pomiaru
+	Points      *float64  // punkty wg Exam.Scoring; nil = odpowiedź sprzed strategii (all-or-nothing)
 }
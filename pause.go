@@ -0,0 +1,219 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Pauza egzaminu ćwiczeniowego: zatrzymuje zegar do limitu Exam.MaxPauseSeconds.
+// Pauza dłuższa niż pozostały limit nie zatrzymuje zegara ponad ten limit.
+// Pauzowanie jest na życzenie: bez maxPauseSec przy starcie egzamin nie ma pauz.
+
+const (
+	defaultMaxPauseSec = 0             // domyślnie bez pauz (np. pełny egzamin próbny)
+	maxMaxPauseSec     = 7 * 24 * 3600 // górna granica limitu z requestu
+)
+
+type ExamClock struct {
+	RemainingSec      int  `json:"remainingSec"`
+	Paused            bool `json:"paused"`
+	PausedSec         int  `json:"pausedSec"` // pauzy wliczone (obcięte do limitu)
+	PauseAllowanceSec int  `json:"pauseAllowanceSec"`
+	PauseLeftSec      int  `json:"pauseLeftSec"`
+}
+
+// computeClock liczy pozostały czas egzaminu na chwilę now (albo FinishedAt).
+func computeClock(e Exam, pauses []ExamPause, now time.Time) ExamClock {
+	end := now
+	if e.FinishedAt != nil {
+		end = *e.FinishedAt
+	}
+	var paused time.Duration
+	open := false
+	for _, p := range pauses {
+		stop := end
+		if p.ResumedAt != nil {
+			stop = *p.ResumedAt
+		} else {
+			open = e.FinishedAt == nil
+		}
+		if stop.After(p.PausedAt) {
+			paused += stop.Sub(p.PausedAt)
+		}
+	}
+	allowance := time.Duration(e.MaxPauseSeconds) * time.Second
+	if paused > allowance {
+		paused = allowance
+	}
+	elapsed := end.Sub(e.StartedAt) - paused
+	remaining := time.Duration(e.DurationSeconds)*time.Second - elapsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	return ExamClock{
+		RemainingSec:      int(remaining / time.Second),
+		Paused:            open,
+		PausedSec:         int(paused / time.Second),
+		PauseAllowanceSec: e.MaxPauseSeconds,
+		PauseLeftSec:      int((allowance - paused) / time.Second),
+	}
+}
+
+func loadPauses(db *gorm.DB, examIDs []string) (map[string][]ExamPause, error) {
+	out := map[string][]ExamPause{}
+	if len(examIDs) == 0 {
+		return out, nil
+	}
+	var ps []ExamPause
+	if err := db.Where("exam_id IN ?", examIDs).Order("paused_at").Find(&ps).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range ps {
+		out[p.ExamID] = append(out[p.ExamID], p)
+	}
+	return out, nil
+}
+
+// examClock ładuje pauzy egzaminu i liczy zegar na teraz.
+func examClock(db *gorm.DB, e *Exam) (ExamClock, error) {
+	ps, err := loadPauses(db, []string{e.ID})
+	if err != nil {
+		return ExamClock{}, err
+	}
+	return computeClock(*e, ps[e.ID], time.Now()), nil
+}
+
+// openPause zwraca trwającą pauzę egzaminu albo nil.
+func openPause(db *gorm.DB, examID string) (*ExamPause, error) {
+	var ps []ExamPause
+	if err := db.Where("exam_id = ? AND resumed_at IS NULL", examID).Limit(1).Find(&ps).Error; err != nil {
+		return nil, err
+	}
+	if len(ps) == 0 {
+		return nil, nil
+	}
+	return &ps[0], nil
+}
+
+// rejectIfPaused odpowiada 409, gdy egzamin jest zapauzowany; false = przerwij handler.
+func rejectIfPaused(c *gin.Context, db *gorm.DB, exam *Exam) bool {
+	p, err := openPause(db, exam.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return false
+	}
+	if p != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "exam is paused"})
+		return false
+	}
+	return true
+}
+
+// loadRunningExam ładuje trwający (nieukończony, nieporzucony, w czasie) egzamin z :id.
+func loadRunningExam(c *gin.Context, db *gorm.DB) (*Exam, bool) {
+	exam, ok := loadOwnExam(c, db)
+	if !ok || !rejectIfClosed(c, db, exam) {
+		return nil, false
+	}
+	return exam, true
+}
+
+// POST /api/v1/exams/:id/pause
+func PauseExam(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exam, ok := loadRunningExam(c, db)
+		if !ok {
+			return
+		}
+		if exam.AssignmentID != nil || exam.MaxPauseSeconds <= 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "this exam can't be paused"})
+			return
+		}
+		clock, err := examClock(db, exam)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		switch {
+		case clock.Paused:
+			c.JSON(http.StatusConflict, gin.H{"error": "exam is already paused", "clock": clock})
+			return
+		case clock.PauseLeftSec <= 0:
+			c.JSON(http.StatusConflict, gin.H{"error": "pause allowance used up", "clock": clock})
+			return
+		case clock.RemainingSec <= 0:
+			c.JSON(http.StatusConflict, gin.H{"error": "exam time is over", "clock": clock})
+			return
+		}
+		if err := db.Create(&ExamPause{ExamID: exam.ID, PausedAt: time.Now()}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		clock, _ = examClock(db, exam)
+		c.JSON(http.StatusOK, gin.H{"clock": clock})
+	}
+}
+
+// POST /api/v1/exams/:id/resume
+func ResumeExam(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exam, ok := loadRunningExam(c, db)
+		if !ok {
+			return
+		}
+		p, err := openPause(db, exam.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		if p == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "exam is not paused"})
+			return
+		}
+		if err := closePause(db, p, time.Now()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		clock, err := examClock(db, exam)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"clock": clock})
+	}
+}
+
+// closePause kończy pauzę i przesuwa otwarte wizyty na pytaniach o jej długość (najwyżej
+// o pozostały limit pauz, jak zegar), żeby czas pauzy nie wliczał się do czasu odpowiedzi.
+func closePause(db *gorm.DB, p *ExamPause, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var exam Exam
+		if err := tx.First(&exam, "id = ?", p.ExamID).Error; err != nil {
+			return err
+		}
+		var earlier []ExamPause
+		if err := tx.Where("exam_id = ? AND id <> ?", p.ExamID, p.ID).Find(&earlier).Error; err != nil {
+			return err
+		}
+		shift := now.Sub(p.PausedAt)
+		if left := time.Duration(computeClock(exam, earlier, p.PausedAt).PauseLeftSec) * time.Second; shift > left {
+			shift = left
+		}
+		if err := tx.Model(p).Update("resumed_at", now).Error; err != nil {
+			return err
+		}
+		var open []ExamQuestion
+		if err := tx.Where("exam_id = ? AND viewed_at IS NOT NULL", p.ExamID).Find(&open).Error; err != nil {
+			return err
+		}
+		for _, eq := range open {
+			if err := tx.Model(&eq).Update("viewed_at", eq.ViewedAt.Add(shift)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestComputeClock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	exam := Exam{ID: "e1", StartedAt: start, DurationSeconds: 3600, MaxPauseSeconds: 600}
+	at := func(min int) time.Time { return start.Add(time.Duration(min) * time.Minute) }
+	resumed := at(15)
+
+	// 20 min od startu, 5 min pauzy → zostało 45 min
+	clock := computeClock(exam, []ExamPause{{PausedAt: at(10), ResumedAt: &resumed}}, at(20))
+	if clock.RemainingSec != 45*60 || clock.Paused || clock.PausedSec != 300 || clock.PauseLeftSec != 300 {
+		t.Errorf("closed pause: %+v", clock)
+	}
+
+	// trwająca pauza zatrzymuje zegar, ale tylko do limitu (10 min)
+	pauses := []ExamPause{{PausedAt: at(10), ResumedAt: &resumed}, {PausedAt: at(20)}}
+	clock = computeClock(exam, pauses, at(22))
+	if !clock.Paused || clock.RemainingSec != 45*60 || clock.PausedSec != 420 {
+		t.Errorf("open pause: %+v", clock)
+	}
+	clock = computeClock(exam, pauses, at(40))
+	if clock.RemainingSec != 30*60 || clock.PausedSec != 600 || clock.PauseLeftSec != 0 {
+		t.Errorf("pause over allowance: %+v", clock)
+	}
+
+	// po czasie zegar stoi na 0
+	if clock := computeClock(exam, nil, at(90)); clock.RemainingSec != 0 {
+		t.Errorf("remaining after time is up: %d", clock.RemainingSec)
+	}
+
+	// ukończony egzamin: zegar zamrożony na FinishedAt
+	finished := at(30)
+	exam.FinishedAt = &finished
+	clock = computeClock(exam, []ExamPause{{PausedAt: at(25)}}, at(300))
+	if clock.Paused || clock.RemainingSec != 35*60 {
+		t.Errorf("finished exam: %+v", clock)
+	}
+}
+
+func TestClosePauseShiftsOpenVisits(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	viewed := now.Add(-10 * time.Minute)
+	db.Create(&Exam{ID: "e1", Type: "exam", StartedAt: now.Add(-time.Hour), DurationSeconds: 7200, MaxPauseSeconds: 3600})
+	db.Create(&ExamQuestion{ExamID: "e1", QuestionID: "q1", Position: 1, ViewedAt: &viewed})
+	db.Create(&ExamQuestion{ExamID: "e1", QuestionID: "q2", Position: 2})
+	p := ExamPause{ExamID: "e1", PausedAt: now.Add(-8 * time.Minute)}
+	db.Create(&p)
+
+	if err := closePause(db, &p, now); err != nil {
+		t.Fatal(err)
+	}
+	if open, _ := openPause(db, "e1"); open != nil {
+		t.Error("pause still open")
+	}
+	var eq ExamQuestion
+	db.First(&eq, "exam_id = ? AND question_id = ?", "e1", "q1")
+	// wizyta trwała 2 min przed pauzą, więc po przesunięciu zaczyna się 2 min temu
+	if eq.ViewedAt == nil || now.Sub(*eq.ViewedAt).Round(time.Second) != 2*time.Minute {
+		t.Errorf("visit not shifted: %v", eq.ViewedAt)
+	}
+	var other ExamQuestion
+	db.First(&other, "exam_id = ? AND question_id = ?", "e1", "q2")
+	if other.ViewedAt != nil {
+		t.Error("unopened question got a visit")
+	}
+}
+
+func TestClosePauseShiftCappedAtAllowance(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	viewed := now.Add(-10 * time.Minute)
+	// limit 5 min, 2 min już wykorzystane: z 8-minutowej pauzy liczą się tylko 3 min
+	db.Create(&Exam{ID: "e1", Type: "exam", StartedAt: now.Add(-time.Hour), DurationSeconds: 7200, MaxPauseSeconds: 300})
+	db.Create(&ExamQuestion{ExamID: "e1", QuestionID: "q1", Position: 1, ViewedAt: &viewed})
+	resumed := now.Add(-28 * time.Minute)
+	db.Create(&ExamPause{ExamID: "e1", PausedAt: now.Add(-30 * time.Minute), ResumedAt: &resumed})
+	p := ExamPause{ExamID: "e1", PausedAt: now.Add(-8 * time.Minute)}
+	db.Create(&p)
+
+	if err := closePause(db, &p, now); err != nil {
+		t.Fatal(err)
+	}
+	var eq ExamQuestion
+	db.First(&eq, "exam_id = ? AND question_id = ?", "e1", "q1")
+	if eq.ViewedAt == nil || now.Sub(*eq.ViewedAt).Round(time.Second) != 7*time.Minute {
+		t.Errorf("visit shifted to %v, want 7 min ago (2 min before + 5 min over the allowance)", eq.ViewedAt)
+	}
+}
+
+func TestPausingIsOptIn(t *testing.T) {
+	f := newAccessFixture(t)
+	f.db.Create(&QuestionBank{ID: "b", Name: "B", IsDefault: true, DefaultQuestionCount: 1, DefaultDurationSec: 600, PassPercent: 61})
+	f.db.Model(&Question{}).Where("id = ?", "q1").Update("bank_id", "b")
+	for body, want := range map[string]float64{`{"count":1}`: 0, `{"count":1,"maxPauseSec":600}`: 600} {
+		w := f.do("POST", "/api/v1/exams", body, f.owner)
+		var out struct {
+			ExamID string         `json:"examId"`
+			Clock  map[string]any `json:"clock"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("start %s: %d %s", body, w.Code, w.Body.String())
+		}
+		if got := out.Clock["pauseAllowanceSec"]; got != want {
+			t.Errorf("start %s: pauseAllowanceSec %v, want %v", body, got, want)
+		}
+		code := f.do("POST", "/api/v1/exams/"+out.ExamID+"/pause", "", f.owner).Code
+		if (want == 0) != (code == http.StatusConflict) {
+			t.Errorf("start %s: pause status %d", body, code)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	ProposalDraft     = "draft"
+	ProposalSubmitted = "submitted"
+	ProposalApproved  = "approved"
+	ProposalRejected  = "rejected"
+)
+
+type ProposalDTO struct {
+	QuestionProposal
+	Question QInput  `json:"question"`
+	Author   *string `json:"author,omitempty"`
+}
+
+type ReviewProposalReq struct {
+	Decision string `json:"decision"` // "approve" | "reject"
+	Comment  string `json:"comment"`
+}
+
+// validateQInput sprawdza pytanie w formacie seeda; zwraca listę problemów.
+func validateQInput(in QInput) []string {
+	var errs []string
+	if strings.TrimSpace(in.QuestionText) == "" {
+		errs = append(errs, "questionText is required")
+	}
+	if len(in.Options) < 2 {
+		errs = append(errs, "at least 2 options are required")
+	}
+	keys := map[string]bool{}
+	for _, o := range in.Options {
+		k := stringsLower(o.ID)
+		if k == "" || len(k) > 4 {
+			errs = append(errs, fmt.Sprintf("option id %q must be 1..4 chars", o.ID))
+			continue
+		}
+		if keys[k] {
+			errs = append(errs, fmt.Sprintf("duplicate option id %q", k))
+		}
+		keys[k] = true
+		if strings.TrimSpace(o.Text) == "" {
+			errs = append(errs, fmt.Sprintf("option %q has no text", k))
+		}
+	}
+	correct := map[string]bool{}
+	for _, k := range in.CorrectOptionIds {
+		k = stringsLower(k)
+		if !keys[k] {
+			errs = append(errs, fmt.Sprintf("correctOptionIds: unknown option %q", k))
+		}
+		correct[k] = true
+	}
+	switch {
+	case len(correct) == 0:
+		errs = append(errs, "at least one correct option is required")
+	case len(correct) == len(keys):
+		errs = append(errs, "at least one option must be incorrect")
+	case len(correct) > 1 && !in.MultiSelect:
+		errs = append(errs, "multiSelect must be true when more than one option is correct")
+	}
+	for lang, items := range map[string][]ExplanationItem{"en": in.OptionsExplanation.EN, "pl": in.OptionsExplanation.PL} {
+		for _, e := range items {
+			k := stringsLower(e.ID)
+			if !keys[k] {
+				errs = append(errs, fmt.Sprintf("optionsExplanation.%s: unknown option %q", lang, k))
+			}
+			if u := strings.TrimSpace(e.URL); u != "" {
+				if pu, err := url.Parse(u); err != nil || (pu.Scheme != "http" && pu.Scheme != "https") {
+					errs = append(errs, fmt.Sprintf("optionsExplanation.%s: option %q url must be http(s)", lang, k))
+				}
+			}
+		}
+	}
+	if len(in.OptionsExplanation.EN) == 0 {
+		errs = append(errs, "optionsExplanation.en is required")
+	}
+	return errs
+}
+
+// nextQuestionID nadaje kolejne numeryczne ID w banku w stylu seeda ("001", "002", ...).
+func nextQuestionID(tx *gorm.DB, bankID string) (string, error) {
+	var ids []string
+	if err := tx.Model(&Question{}).Where("bank_id = ?", bankID).Pluck("id", &ids).Error; err != nil {
+		return "", err
+	}
+	max := 0
+	for _, id := range ids {
+		if n, err := strconv.Atoi(localQuestionID(id)); err == nil && n > max {
+			max = n
+		}
+	}
+	return fmt.Sprintf("%03d", max+1), nil
+}
+
+func toProposalDTO(db *gorm.DB, p QuestionProposal) ProposalDTO {
+	dto := ProposalDTO{QuestionProposal: p}
+	_ = json.Unmarshal([]byte(p.Payload), &dto.Question)
+	var u User
+	if err := db.First(&u, p.AuthorID).Error; err == nil {
+		dto.Author = u.DisplayName
+	}
+	return dto
+}
+
+// bindProposal parsuje i waliduje QInput z body; błędy zapisuje do odpowiedzi.
+func bindProposal(c *gin.Context) (string, bool) {
+	var in QInput
+	if err := c.BindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+		return "", false
+	}
+	in.ID = "" // ID nadajemy przy zatwierdzeniu
+	if errs := validateQInput(in); len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid question", "details": errs})
+		return "", false
+	}
+	raw, _ := json.Marshal(in)
+	return string(raw), true
+}
+
+// loadProposal ładuje propozycję z :id; autor widzi swoje, reviewer/admin wszystkie.
+func loadProposal(c *gin.Context, db *gorm.DB) (*QuestionProposal, bool) {
+	uid, _ := currentUserID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad proposal id"})
+		return nil, false
+	}
+	var p QuestionProposal
+	if err := db.First(&p, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "proposal not found"})
+		return nil, false
+	}
+	if p.AuthorID != uid && !hasRole(c, RoleReviewer) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return nil, false
+	}
+	return &p, true
+}
+
+// POST /api/v1/proposals?bank=commerce-dev (author)
+func CreateProposal(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := currentUserID(c)
+		bank, ok := bankFromRequest(c, db, c.Query("bank"))
+		if !ok {
+			return
+		}
+		payload, ok := bindProposal(c)
+		if !ok {
+			return
+		}
+		p := QuestionProposal{AuthorID: uid, BankID: bank.ID, Payload: payload, Status: ProposalDraft}
+		if err := db.Create(&p).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusCreated, toProposalDTO(db, p))
+	}
+}
+
+// GET /api/v1/proposals?status=submitted&bank=
+// Autor widzi swoje propozycje, reviewer/admin — wszystkie.
+func ListProposals(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := currentUserID(c)
+		tx := db.Order("updated_at DESC")
+		if !hasRole(c, RoleReviewer) || c.Query("mine") == "true" {
+			tx = tx.Where("author_id = ?", uid)
+		}
+		if st := c.Query("status"); st != "" {
+			tx = tx.Where("status = ?", st)
+		}
+		if bank := c.Query("bank"); bank != "" {
+			tx = tx.Where("bank_id = ?", bank)
+		}
+		var ps []QuestionProposal
+		if err := tx.Find(&ps).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		out := make([]ProposalDTO, 0, len(ps))
+		for _, p := range ps {
+			out = append(out, toProposalDTO(db, p))
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// GET /api/v1/proposals/:id
+func GetProposal(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := loadProposal(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, toProposalDTO(db, *p))
+	}
+}
+
+// PUT /api/v1/proposals/:id — autor edytuje draft albo odrzuconą propozycję (wraca do draft)
+func UpdateProposal(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := currentUserID(c)
+		p, ok := loadProposal(c, db)
+		if !ok {
+			return
+		}
+		if p.AuthorID != uid {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the author can edit"})
+			return
+		}
+		if p.Status != ProposalDraft && p.Status != ProposalRejected {
+			c.JSON(http.StatusConflict, gin.H{"error": "only draft or rejected proposals can be edited"})
+			return
+		}
+		payload, ok := bindProposal(c)
+		if !ok {
+			return
+		}
+		p.Payload, p.Status = payload, ProposalDraft
+		if err := db.Save(p).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, toProposalDTO(db, *p))
+	}
+}
+
+// POST /api/v1/proposals/:id/submit — autor wysyła draft do review
+func SubmitProposal(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := currentUserID(c)
+		p, ok := loadProposal(c, db)
+		if !ok {
+			return
+		}
+		if p.AuthorID != uid {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the author can submit"})
+			return
+		}
+		if p.Status != ProposalDraft {
+			c.JSON(http.StatusConflict, gin.H{"error": "only draft proposals can be submitted"})
+			return
+		}
+		p.Status = ProposalSubmitted
+		if err := db.Save(p).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, toProposalDTO(db, *p))
+	}
+}
+
+var errNotSubmitted = errors.New("not submitted")
+
+// POST /api/v1/proposals/:id/review (reviewer)
+// approve wstawia pytanie jako żywe Question/Option/Explanation z atrybucją autora.
+func ReviewProposal(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := currentUserID(c)
+		var req ReviewProposalReq
+		if err := c.BindJSON(&req); err != nil || (req.Decision != "approve" && req.Decision != "reject") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "decision must be approve|reject"})
+			return
+		}
+		comment := strings.TrimSpace(req.Comment)
+		if req.Decision == "reject" && comment == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "comment required when rejecting"})
+			return
+		}
+		p, ok := loadProposal(c, db)
+		if !ok {
+			return
+		}
+		if p.AuthorID == uid && !isAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "cannot review own proposal"})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// status sprawdzamy w transakcji, żeby dwa review nie wstawiły pytania dwa razy
+			if err := tx.First(p, p.ID).Error; err != nil {
+				return err
+			}
+			if p.Status != ProposalSubmitted {
+				return errNotSubmitted
+			}
+			now := time.Now()
+			p.ReviewerID, p.ReviewedAt, p.ReviewComment = &uid, &now, comment
+			if req.Decision == "reject" {
+				p.Status = ProposalRejected
+				return tx.Save(p).Error
+			}
+
+			var in QInput
+			if err := json.Unmarshal([]byte(p.Payload), &in); err != nil {
+				return err
+			}
+			localID, err := nextQuestionID(tx, p.BankID)
+			if err != nil {
+				return err
+			}
+			in.ID = localID
+			author := p.AuthorID
+			id, err := insertQuestion(tx, p.BankID, in, &author)
+			if err != nil {
+				return err
+			}
+			p.Status, p.PublishedQuestionID = ProposalApproved, &id
+			return tx.Save(p).Error
+		})
+		switch {
+		case errors.Is(err, errNotSubmitted):
+			c.JSON(http.StatusConflict, gin.H{"error": "only submitted proposals can be reviewed"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, toProposalDTO(db, *p))
+	}
+}
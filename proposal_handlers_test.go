@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func validProposal() QInput {
+	return QInput{
+		QuestionText: "Which transaction creates a sales order?",
+		Options: []QInputOption{
+			{ID: "a", Text: "VA01"},
+			{ID: "b", Text: "ME21N"},
+		},
+		CorrectOptionIds: []string{"a"},
+		OptionsExplanation: OptionsExplanation{
+			EN: []ExplanationItem{
+				{ID: "a", Text: "VA01 creates sales orders.", URL: "https://help.sap.com"},
+				{ID: "b", Text: "ME21N creates purchase orders."},
+			},
+		},
+	}
+}
+
+func TestValidateQInput(t *testing.T) {
+	if errs := validateQInput(validProposal()); len(errs) != 0 {
+		t.Fatalf("valid proposal rejected: %v", errs)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*QInput)
+		want   string
+	}{
+		{"empty text", func(q *QInput) { q.QuestionText = "  " }, "questionText"},
+		{"one option", func(q *QInput) { q.Options = q.Options[:1]; q.OptionsExplanation.EN = q.OptionsExplanation.EN[:1] }, "at least 2 options"},
+		{"duplicate option", func(q *QInput) { q.Options[1].ID = "A" }, "duplicate"},
+		{"unknown correct", func(q *QInput) { q.CorrectOptionIds = []string{"z"} }, "unknown option"},
+		{"no correct", func(q *QInput) { q.CorrectOptionIds = nil }, "at least one correct"},
+		{"all correct", func(q *QInput) { q.CorrectOptionIds = []string{"a", "b"}; q.MultiSelect = true }, "must be incorrect"},
+		{"multi without flag", func(q *QInput) {
+			q.Options = append(q.Options, QInputOption{ID: "c", Text: "VF01"})
+			q.CorrectOptionIds = []string{"a", "c"}
+		}, "multiSelect"},
+		{"bad url", func(q *QInput) { q.OptionsExplanation.EN[0].URL = "javascript:alert(1)" }, "http(s)"},
+		{"no en explanation", func(q *QInput) { q.OptionsExplanation.EN = nil }, "optionsExplanation.en"},
+	}
+	for _, tt := range tests {
+		q := validProposal()
+		tt.modify(&q)
+		errs := validateQInput(q)
+		if !strings.Contains(strings.Join(errs, "; "), tt.want) {
+			t.Errorf("%s: errors %v, want one containing %q", tt.name, errs, tt.want)
+		}
+	}
+}
+
+func TestNextQuestionID(t *testing.T) {
+	db := newTestDB(t)
+	for _, q := range []Question{
+		{ID: "dev:001", BankID: "dev"},
+		{ID: "dev:009", BankID: "dev"},
+		{ID: "dev:custom", BankID: "dev"},
+		{ID: "cx:042", BankID: "cx"},
+	} {
+		q.TextEN = "q"
+		if err := db.Create(&q).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	id, err := nextQuestionID(db, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "010" {
+		t.Errorf("nextQuestionID = %q, want 010", id)
+	}
+}
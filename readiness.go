@@ -0,0 +1,346 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Gotowość do egzaminu: szacowany wynik i szansa zdania liczone z historii odpowiedzi.
+//
+// Model (celowo prosty i wyjaśnialny):
+//   - każda odpowiedź ma wagę 0.5^(wiek/readinessHalfLife), więc stare wyniki liczą się mniej;
+//   - per domena (tag) skuteczność to średnia Beta(1,1) z ważonych odpowiedzi,
+//     domena bez odpowiedzi ma 50% i dużą niepewność;
+//   - niepewność liczymy od liczby różnych widzianych pytań, nie odpowiedzi:
+//     powtarzanie tych samych pytań nie mówi nic o pozostałej części banku;
+//   - szacowany wynik to średnia domen ważona ich udziałem w banku;
+//   - jeśli są próbne egzaminy, wynik mieszamy po połowie z ich trendem;
+//   - szansa zdania = P(wynik ≥ próg) przy rozkładzie normalnym z niepewnością
+//     oszacowania i losowaniem pytań na egzaminie.
+
+const (
+	readinessHalfLife    = 14 * 24 * time.Hour
+	readinessMinAnswers  = 20 // poniżej: insufficientData
+	readinessTrendWindow = 10 // ostatnie N egzaminów do trendu
+	untaggedDomain       = "untagged"
+)
+
+type readinessAnswer struct {
+	QuestionID string
+	IsCorrect  bool
+	AnsweredAt time.Time
+}
+
+type readinessExam struct {
+	FinishedAt time.Time
+	Score      float64
+}
+
+// readinessInput to wszystko, czego potrzebuje computeReadiness (bez dostępu do bazy).
+type readinessInput struct {
+	PassPercent   float64
+	ExamQuestions int                 // liczba pytań na egzaminie (szum losowania)
+	QuestionTags  map[string][]string // wszystkie pytania banku -> domeny
+	Answers       []readinessAnswer
+	Exams         []readinessExam // zakończone egzaminy, rosnąco po czasie
+}
+
+type Interval struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+type DomainReadiness struct {
+	Domain    string   `json:"domain"`
+	BankShare float64  `json:"bankShare"` // % pytań banku w tej domenie
+	Questions int      `json:"questions"` // pytań w banku
+	Seen      int      `json:"seen"`      // różnych pytań, na które user odpowiadał
+	Answered  int      `json:"answered"`  // odpowiedzi (z powtórkami)
+	Accuracy  float64  `json:"accuracy"`  // ważona czasem, %
+	CI95      Interval `json:"ci95"`      // przedział dla accuracy
+	Impact    float64  `json:"impact"`    // wkład w (wynik - próg), punkty procentowe
+	DragsDown bool     `json:"dragsDown"` // poniżej progu
+}
+
+type ExamTrend struct {
+	Exams     int      `json:"exams"`
+	LastScore *float64 `json:"lastScore,omitempty"`
+	Recent    *float64 `json:"recentAverage,omitempty"` // średnia z ostatnich 3
+	Slope     *float64 `json:"slopePerExam,omitempty"`  // zmiana wyniku na egzamin (regresja)
+	Projected *float64 `json:"projected,omitempty"`     // recentAverage + slope, w [0,100]
+}
+
+type ReadinessResponse struct {
+	Bank             string            `json:"bank"`
+	PassPercent      float64           `json:"passPercent"`
+	EstimatedScore   float64           `json:"estimatedScore"`
+	ScoreCI95        Interval          `json:"scoreCi95"`
+	PassProbability  float64           `json:"passProbability"` // 0..1
+	Level            string            `json:"level"`           // ready | almost | not_ready
+	InsufficientData bool              `json:"insufficientData"`
+	Coverage         float64           `json:"coverage"` // % pytań banku, na które user odpowiadał
+	SeenQuestions    int               `json:"seenQuestions"`
+	BankQuestions    int               `json:"bankQuestions"`
+	TotalAnswers     int               `json:"totalAnswers"`
+	Trend            ExamTrend         `json:"trend"`
+	Domains          []DomainReadiness `json:"domains"` // najbardziej obniżające wynik na początku
+}
+
+func recencyWeight(age time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(readinessHalfLife))
+}
+
+// normalCDF to dystrybuanta rozkładu normalnego N(0,1).
+func normalCDF(z float64) float64 {
+	return 0.5 * (1 + math.Erf(z/math.Sqrt2))
+}
+
+func clampPercent(v float64) float64 {
+	return math.Max(0, math.Min(100, v))
+}
+
+// examTrend liczy średnią z ostatnich 3 egzaminów i nachylenie regresji liniowej
+// wyniku względem numeru egzaminu (ostatnie readinessTrendWindow).
+func examTrend(exams []readinessExam) ExamTrend {
+	t := ExamTrend{Exams: len(exams)}
+	if len(exams) == 0 {
+		return t
+	}
+	if len(exams) > readinessTrendWindow {
+		exams = exams[len(exams)-readinessTrendWindow:]
+	}
+	last := exams[len(exams)-1].Score
+	t.LastScore = &last
+
+	k := 3
+	if len(exams) < k {
+		k = len(exams)
+	}
+	var sum float64
+	for _, e := range exams[len(exams)-k:] {
+		sum += e.Score
+	}
+	recent := sum / float64(k)
+	t.Recent = &recent
+	projected := recent
+
+	if n := float64(len(exams)); len(exams) >= 2 {
+		var sx, sy, sxy, sxx float64
+		for i, e := range exams {
+			x := float64(i)
+			sx += x
+			sy += e.Score
+			sxy += x * e.Score
+			sxx += x * x
+		}
+		slope := (n*sxy - sx*sy) / (n*sxx - sx*sx)
+		t.Slope = &slope
+		projected = recent + slope
+	}
+	projected = clampPercent(projected)
+	t.Projected = &projected
+	return t
+}
+
+func computeReadiness(in readinessInput, now time.Time) ReadinessResponse {
+	resp := ReadinessResponse{
+		PassPercent:   in.PassPercent,
+		BankQuestions: len(in.QuestionTags),
+		TotalAnswers:  len(in.Answers),
+		Trend:         examTrend(in.Exams),
+		Domains:       []DomainReadiness{},
+	}
+	threshold := in.PassPercent / 100
+
+	type domainAcc struct {
+		DomainReadiness
+		wCorrect, wTotal float64
+		seen             map[string]bool
+	}
+	domains := map[string]*domainAcc{}
+	domainOf := func(name string) *domainAcc {
+		d := domains[name]
+		if d == nil {
+			d = &domainAcc{DomainReadiness: DomainReadiness{Domain: name}, seen: map[string]bool{}}
+			domains[name] = d
+		}
+		return d
+	}
+	tagsOf := func(qid string) []string {
+		if tags := in.QuestionTags[qid]; len(tags) > 0 {
+			return tags
+		}
+		return []string{untaggedDomain}
+	}
+
+	// udział domen w banku (pytanie z kilkoma tagami dzieli się między nie)
+	var shareTotal float64
+	for qid := range in.QuestionTags {
+		tags := tagsOf(qid)
+		for _, tag := range tags {
+			d := domainOf(tag)
+			d.Questions++
+			d.BankShare += 1 / float64(len(tags))
+		}
+		shareTotal++
+	}
+
+	seen := map[string]bool{}
+	for _, a := range in.Answers {
+		if _, inBank := in.QuestionTags[a.QuestionID]; !inBank {
+			continue // pytanie usunięte z banku
+		}
+		seen[a.QuestionID] = true
+		w := recencyWeight(now.Sub(a.AnsweredAt))
+		for _, tag := range tagsOf(a.QuestionID) {
+			d := domainOf(tag)
+			d.Answered++
+			d.seen[a.QuestionID] = true
+			d.wTotal += w
+			if a.IsCorrect {
+				d.wCorrect += w
+			}
+		}
+	}
+	resp.SeenQuestions = len(seen)
+	if resp.BankQuestions > 0 {
+		resp.Coverage = float64(resp.SeenQuestions) * 100 / float64(resp.BankQuestions)
+	}
+
+	// szacowany wynik z domen: Σ w_d * p_d, wariancja Σ w_d² * var_d
+	var mean, variance float64
+	for _, d := range domains {
+		share := 0.0
+		if shareTotal > 0 {
+			share = d.BankShare / shareTotal
+		}
+		p := (d.wCorrect + 1) / (d.wTotal + 2)
+		v := p * (1 - p) / (math.Min(d.wTotal, float64(len(d.seen))) + 3)
+		mean += share * p
+		variance += share * share * v
+
+		half := 1.96 * math.Sqrt(v)
+		d.Seen = len(d.seen)
+		d.BankShare = share * 100
+		d.Accuracy = p * 100
+		d.CI95 = Interval{Low: clampPercent((p - half) * 100), High: clampPercent((p + half) * 100)}
+		d.Impact = share * (p - threshold) * 100
+		d.DragsDown = p < threshold
+		resp.Domains = append(resp.Domains, d.DomainReadiness)
+	}
+	sort.Slice(resp.Domains, func(i, j int) bool {
+		if resp.Domains[i].Impact != resp.Domains[j].Impact {
+			return resp.Domains[i].Impact < resp.Domains[j].Impact
+		}
+		return resp.Domains[i].Domain < resp.Domains[j].Domain
+	})
+
+	// mieszanka z trendem próbnych egzaminów
+	if resp.Trend.Projected != nil {
+		mock := *resp.Trend.Projected / 100
+		// niepewność trendu: rozrzut wyniku pojedynczego egzaminu
+		mockVar := mock * (1 - mock) / float64(maxInt(in.ExamQuestions, 1)) / float64(minInt(len(in.Exams), 3))
+		mean = (mean + mock) / 2
+		variance = (variance + mockVar) / 4
+	}
+
+	n := float64(maxInt(in.ExamQuestions, 1))
+	examVar := variance + mean*(1-mean)/n // + losowanie pytań na egzaminie
+	resp.EstimatedScore = mean * 100
+	half := 1.96 * math.Sqrt(variance)
+	resp.ScoreCI95 = Interval{Low: clampPercent((mean - half) * 100), High: clampPercent((mean + half) * 100)}
+	if examVar > 0 {
+		resp.PassProbability = 1 - normalCDF((threshold-mean)/math.Sqrt(examVar))
+	} else if mean >= threshold {
+		resp.PassProbability = 1 // pusty bank albo pewny wynik
+	}
+
+	resp.InsufficientData = len(in.Answers) < readinessMinAnswers
+	switch {
+	case resp.PassProbability >= 0.8:
+		resp.Level = "ready"
+	case resp.PassProbability >= 0.5:
+		resp.Level = "almost"
+	default:
+		resp.Level = "not_ready"
+	}
+	return resp
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// loadReadinessInput zbiera odpowiedzi i egzaminy usera z jednego banku.
+func loadReadinessInput(db *gorm.DB, uid uint, bank *QuestionBank) (readinessInput, error) {
+	in := readinessInput{
+		PassPercent:   bank.PassPercent,
+		ExamQuestions: bank.DefaultQuestionCount,
+		QuestionTags:  map[string][]string{},
+	}
+	var qs []Question
+	if err := db.Select("id", "tags").Where("bank_id = ?", bank.ID).Find(&qs).Error; err != nil {
+		return in, err
+	}
+	for _, q := range qs {
+		in.QuestionTags[q.ID] = splitTags(q.Tags)
+	}
+
+	if err := db.Table("answers a").
+		Select("a.question_id as question_id, a.is_correct as is_correct, a.answered_at as answered_at").
+		Joins("JOIN exams e ON e.id = a.exam_id").
+		Where("e.user_id = ? AND e.bank_id = ? AND e.status <> ?", uid, bank.ID, ExamStatusAbandoned).
+		Scan(&in.Answers).Error; err != nil {
+		return in, err
+	}
+
+	if err := db.Table("exams").
+		Select("finished_at, score_percent as score").
+		Where("user_id = ? AND bank_id = ? AND type = ? AND score_percent IS NOT NULL", uid, bank.ID, "exam").
+		Order("finished_at").
+		Scan(&in.Exams).Error; err != nil {
+		return in, err
+	}
+	return in, nil
+}
+
+// GET /api/v1/stats/readiness?bank=
+func Readiness(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		bank, ok := bankFromRequest(c, db, c.Query("bank"))
+		if !ok {
+			return
+		}
+		in, err := loadReadinessInput(db, uid, bank)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		resp := computeReadiness(in, time.Now())
+		resp.Bank = bank.ID
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func readinessBank(tags map[string]int) map[string][]string {
+	out := map[string][]string{}
+	for tag, n := range tags {
+		for i := 0; i < n; i++ {
+			out[fmt.Sprintf("%s-%d", tag, i)] = []string{tag}
+		}
+	}
+	return out
+}
+
+func answerAll(bank map[string][]string, tag string, correct bool, at time.Time) []readinessAnswer {
+	var out []readinessAnswer
+	for qid, tags := range bank {
+		if tags[0] == tag {
+			out = append(out, readinessAnswer{QuestionID: qid, IsCorrect: correct, AnsweredAt: at})
+		}
+	}
+	return out
+}
+
+func TestComputeReadinessBreakdown(t *testing.T) {
+	now := time.Now()
+	bank := readinessBank(map[string]int{"OMS": 30, "Solr": 10})
+	in := readinessInput{PassPercent: 61, ExamQuestions: 80, QuestionTags: bank}
+	in.Answers = append(answerAll(bank, "OMS", true, now), answerAll(bank, "Solr", false, now)...)
+
+	r := computeReadiness(in, now)
+	if r.Coverage != 100 || r.SeenQuestions != 40 || r.InsufficientData {
+		t.Errorf("coverage = %v seen = %d insufficient = %v", r.Coverage, r.SeenQuestions, r.InsufficientData)
+	}
+	if len(r.Domains) != 2 || r.Domains[0].Domain != "Solr" || !r.Domains[0].DragsDown || r.Domains[1].DragsDown {
+		t.Fatalf("domains = %+v, want Solr first and dragging down", r.Domains)
+	}
+	if math.Abs(r.Domains[1].BankShare-75) > 1e-9 {
+		t.Errorf("OMS share = %v, want 75", r.Domains[1].BankShare)
+	}
+	// 75% banku prawie bezbłędnie, 25% źle → ok. 72%
+	if r.EstimatedScore < 68 || r.EstimatedScore > 76 {
+		t.Errorf("estimated score = %.1f, want ~72", r.EstimatedScore)
+	}
+	if r.ScoreCI95.Low > r.EstimatedScore || r.ScoreCI95.High < r.EstimatedScore {
+		t.Errorf("CI %+v doesn't contain estimate %.1f", r.ScoreCI95, r.EstimatedScore)
+	}
+	if r.PassProbability < 0.5 || r.PassProbability > 1 {
+		t.Errorf("pass probability = %v", r.PassProbability)
+	}
+}
+
+func TestComputeReadinessRecencyAndCoverage(t *testing.T) {
+	now := time.Now()
+	bank := readinessBank(map[string]int{"OMS": 20})
+
+	// dawne błędy, świeże poprawne odpowiedzi → wynik bliżej świeżych
+	in := readinessInput{PassPercent: 61, ExamQuestions: 80, QuestionTags: bank}
+	in.Answers = append(answerAll(bank, "OMS", false, now.AddDate(0, -3, 0)), answerAll(bank, "OMS", true, now)...)
+	if r := computeReadiness(in, now); r.EstimatedScore < 90 {
+		t.Errorf("recent answers should dominate, got %.1f", r.EstimatedScore)
+	}
+
+	// ta sama skuteczność, ale tylko 2 pytania widziane wielokrotnie → szerszy przedział
+	var repeated []readinessAnswer
+	for i := 0; i < 10; i++ {
+		repeated = append(repeated,
+			readinessAnswer{QuestionID: "OMS-0", IsCorrect: true, AnsweredAt: now},
+			readinessAnswer{QuestionID: "OMS-1", IsCorrect: true, AnsweredAt: now})
+	}
+	wide := computeReadiness(readinessInput{PassPercent: 61, ExamQuestions: 80, QuestionTags: bank, Answers: repeated}, now)
+	narrow := computeReadiness(readinessInput{PassPercent: 61, ExamQuestions: 80, QuestionTags: bank, Answers: answerAll(bank, "OMS", true, now)}, now)
+	if wide.ScoreCI95.High-wide.ScoreCI95.Low <= narrow.ScoreCI95.High-narrow.ScoreCI95.Low {
+		t.Errorf("low coverage CI %+v should be wider than full coverage CI %+v", wide.ScoreCI95, narrow.ScoreCI95)
+	}
+	if wide.Coverage != 10 {
+		t.Errorf("coverage = %v, want 10", wide.Coverage)
+	}
+}
+
+func TestExamTrend(t *testing.T) {
+	if tr := examTrend(nil); tr.Projected != nil || tr.Exams != 0 {
+		t.Errorf("empty trend = %+v", tr)
+	}
+	tr := examTrend([]readinessExam{{Score: 50}, {Score: 60}, {Score: 70}})
+	if tr.Slope == nil || math.Abs(*tr.Slope-10) > 1e-9 {
+		t.Fatalf("slope = %v, want 10", tr.Slope)
+	}
+	if *tr.Recent != 60 || *tr.Projected != 70 || *tr.LastScore != 70 {
+		t.Errorf("trend = recent %v projected %v last %v", *tr.Recent, *tr.Projected, *tr.LastScore)
+	}
+	if tr := examTrend([]readinessExam{{Score: 95}, {Score: 99}}); *tr.Projected != 100 {
+		t.Errorf("projected should be clamped to 100, got %v", *tr.Projected)
+	}
+}
+
+func TestComputeReadinessEmptyBank(t *testing.T) {
+	r := computeReadiness(readinessInput{PassPercent: 61, ExamQuestions: 80, QuestionTags: map[string][]string{}}, time.Now())
+	if math.IsNaN(r.PassProbability) || r.PassProbability != 0 || r.Level != "not_ready" || !r.InsufficientData {
+		t.Errorf("empty bank readiness = %+v", r)
+	}
+}
+
+func TestLoadReadinessInputSkipsAbandonedExams(t *testing.T) {
+	db := newTestDB(t)
+	uid := uint(1)
+	now := time.Now()
+	bank := QuestionBank{ID: "b", Name: "B", DefaultQuestionCount: 2, DefaultDurationSec: 60, PassPercent: 61}
+	db.Create(&bank)
+	db.Create(&Question{ID: "b:1", BankID: "b", TextEN: "q"})
+	db.Create(&Exam{ID: "kept", Type: "exam", UserID: &uid, BankID: "b", StartedAt: now, DurationSeconds: 60, Status: ExamStatusInProgress})
+	db.Create(&Exam{ID: "gone", Type: "exam", UserID: &uid, BankID: "b", StartedAt: now, DurationSeconds: 60, Status: ExamStatusAbandoned})
+	db.Create(&Answer{ExamID: "kept", QuestionID: "b:1", SelectedRaw: `["a"]`, IsCorrect: true, AnsweredAt: now})
+	db.Create(&Answer{ExamID: "gone", QuestionID: "b:1", SelectedRaw: `["b"]`, AnsweredAt: now})
+
+	in, err := loadReadinessInput(db, uid, &bank)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(in.Answers) != 1 || !in.Answers[0].IsCorrect {
+		t.Errorf("answers = %+v, want only the one from the kept exam", in.Answers)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	ReportOpen     = "open"
+	ReportAccepted = "accepted"
+	ReportRejected = "rejected"
+	ReportFixed    = "fixed"
+)
+
+var reportCategories = map[string]bool{
+	"wrong_answer":         true, // błędne correctOptionIds
+	"outdated_explanation": true,
+	"broken_link":          true,
+	"typo":                 true,
+	"other":                true,
+}
+
+// reportTransitions: dozwolone zmiany statusu zgłoszenia. fixed jest końcowy,
+// odrzucone zgłoszenie można ponownie otworzyć.
+var reportTransitions = map[string][]string{
+	ReportOpen:     {ReportAccepted, ReportRejected, ReportFixed},
+	ReportAccepted: {ReportFixed, ReportRejected},
+	ReportRejected: {ReportOpen},
+}
+
+func canTransitionReport(from, to string) bool {
+	return containsString(reportTransitions[from], to)
+}
+
+type CreateReportReq struct {
+	Category string `json:"category"`
+	Comment  string `json:"comment"`
+}
+
+type UpdateReportReq struct {
+	Status      string `json:"status"`
+	Note        string `json:"note"`
+	BumpVersion *bool  `json:"bumpVersion"` // przy fixed: default true; false = poprawka już w bieżącej wersji
+}
+
+type ReportQueueItem struct {
+	QuestionID   string           `json:"questionId"`
+	QuestionText string           `json:"questionText"`
+	Version      int              `json:"version"`
+	Counts       map[string]int   `json:"counts"` // status -> liczba
+	Reports      []QuestionReport `json:"reports"`
+}
+
+// POST /api/v1/questions/:id/report
+func ReportQuestion(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			return
+		}
+		var q Question
+		if err := db.First(&q, "id = ?", c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "question not found"})
+			return
+		}
+		var req CreateReportReq
+		if err := c.BindJSON(&req); err != nil || !reportCategories[req.Category] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category must be wrong_answer|outdated_explanation|broken_link|typo|other"})
+			return
+		}
+		comment := strings.TrimSpace(req.Comment)
+		if len(comment) > 2000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "comment too long (max 2000 chars)"})
+			return
+		}
+		// jedno otwarte zgłoszenie per user i pytanie
+		var existing int64
+		_ = db.Model(&QuestionReport{}).
+			Where("question_id = ? AND user_id = ? AND status IN ?", q.ID, uid, []string{ReportOpen, ReportAccepted}).
+			Count(&existing).Error
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "you already reported this question"})
+			return
+		}
+
+		r := QuestionReport{QuestionID: q.ID, UserID: uid, Category: req.Category, Comment: comment, Status: ReportOpen}
+		if err := db.Create(&r).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusCreated, r)
+	}
+}
+
+// GET /api/v1/admin/reports?status=open
+// Kolejka moderacji: zgłoszenia pogrupowane per pytanie, najwięcej otwartych na początku.
+func ListReports(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tx := db.Order("created_at")
+		if st := c.Query("status"); st != "" {
+			tx = tx.Where("status = ?", st)
+		}
+		var reports []QuestionReport
+		if err := tx.Find(&reports).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+
+		byQ := map[string]*ReportQueueItem{}
+		var qids []string
+		for _, r := range reports {
+			it := byQ[r.QuestionID]
+			if it == nil {
+				it = &ReportQueueItem{QuestionID: r.QuestionID, Counts: map[string]int{}}
+				byQ[r.QuestionID] = it
+				qids = append(qids, r.QuestionID)
+			}
+			it.Counts[r.Status]++
+			it.Reports = append(it.Reports, r)
+		}
+		if len(qids) > 0 {
+			var qs []Question
+			if err := db.Where("id IN ?", qids).Find(&qs).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			for _, q := range qs {
+				byQ[q.ID].QuestionText = q.TextEN
+				byQ[q.ID].Version = q.Version
+			}
+		}
+
+		out := make([]ReportQueueItem, 0, len(qids))
+		for _, id := range qids {
+			out = append(out, *byQ[id])
+		}
+		sort.SliceStable(out, func(i, j int) bool {
+			if out[i].Counts[ReportOpen] != out[j].Counts[ReportOpen] {
+				return out[i].Counts[ReportOpen] > out[j].Counts[ReportOpen]
+			}
+			return len(out[i].Reports) > len(out[j].Reports)
+		})
+		c.JSON(http.StatusOK, gin.H{"total": len(reports), "questions": out})
+	}
+}
+
+var errBadTransition = errors.New("bad transition")
+
+// PATCH /api/v1/admin/reports/:id
+// Zmiana statusu zgłoszenia. Przejście na fixed podbija Question.Version
+// (chyba że bumpVersion=false) i zapisuje ją w FixedInVersion.
+func UpdateReport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := currentUserID(c)
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad report id"})
+			return
+		}
+		var req UpdateReportReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+
+		var r QuestionReport
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.First(&r, id).Error; err != nil {
+				return err
+			}
+			if !canTransitionReport(r.Status, req.Status) {
+				return errBadTransition
+			}
+			if req.Status == ReportFixed {
+				var q Question
+				if err := tx.First(&q, "id = ?", r.QuestionID).Error; err != nil {
+					return err
+				}
+				if req.BumpVersion == nil || *req.BumpVersion {
+					q.Version++
+					if err := tx.Model(&q).Update("version", q.Version).Error; err != nil {
+						return err
+					}
+				}
+				v := q.Version
+				r.FixedInVersion = &v
+			}
+			r.Status = req.Status
+			if note := strings.TrimSpace(req.Note); note != "" {
+				r.ResolutionNote = note
+			}
+			if req.Status == ReportOpen {
+				r.ResolvedBy, r.ResolvedAt = nil, nil
+			} else {
+				now := time.Now()
+				r.ResolvedBy, r.ResolvedAt = &uid, &now
+			}
+			return tx.Save(&r).Error
+		})
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+			return
+		case errors.Is(err, errBadTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": "cannot change status from " + r.Status + " to " + req.Status})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		c.JSON(http.StatusOK, r)
+	}
+}
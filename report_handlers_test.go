@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestCanTransitionReport(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{ReportOpen, ReportAccepted, true},
+		{ReportOpen, ReportFixed, true},
+		{ReportAccepted, ReportFixed, true},
+		{ReportAccepted, ReportOpen, false},
+		{ReportRejected, ReportOpen, true},
+		{ReportRejected, ReportFixed, false},
+		{ReportFixed, ReportOpen, false},
+		{ReportOpen, "bogus", false},
+	}
+	for _, tt := range tests {
+		if got := canTransitionReport(tt.from, tt.to); got != tt.want {
+			t.Errorf("canTransitionReport(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Powtórka ukończonego egzaminu: nowy Exam z ParentExamID = egzamin źródłowy.
+const (
+	RetakeSame     = "same"     // te same pytania w tej samej kolejności
+	RetakeShuffled = "shuffled" // te same pytania, nowa kolejność
+	RetakeWrong    = "wrong"    // tylko błędne i pominięte
+)
+
+var retakeModes = []string{RetakeSame, RetakeShuffled, RetakeWrong}
+
+type RetakeReq struct {
+	Mode string `json:"mode"` // domyślnie same
+	Seed *int64 `json:"seed"` // shuffled: powtarzalna kolejność
+}
+
+// retakeQuestions wybiera pytania powtórki z review rodzica (w kolejności egzaminu).
+func retakeQuestions(mode string, review []ReviewRow, seed *int64) []string {
+	var qids []string
+	for _, r := range review {
+		if mode == RetakeWrong && r.WasCorrect {
+			continue
+		}
+		qids = append(qids, r.QuestionID)
+	}
+	if mode == RetakeShuffled {
+		qids = drawQuestions(qids, len(qids), seed)
+	}
+	return qids
+}
+
+// retakeDuration skraca czas proporcjonalnie do liczby pytań (w górę do pełnej minuty).
+func retakeDuration(parent Exam, total, n int) int {
+	if total == 0 || n >= total {
+		return parent.DurationSeconds
+	}
+	sec := (parent.DurationSeconds*n + total - 1) / total
+	return (sec + 59) / 60 * 60
+}
+
+// POST /api/v1/exams/:id/retake
+func RetakeExam(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parent, ok := loadOwnExam(c, db)
+		if !ok {
+			return
+		}
+		var req RetakeReq
+		_ = c.ShouldBindJSON(&req)
+		if req.Mode == "" {
+			req.Mode = RetakeSame
+		}
+		if !containsString(retakeModes, req.Mode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be same|shuffled|wrong"})
+			return
+		}
+		switch {
+		case parent.FinishedAt == nil:
+			c.JSON(http.StatusConflict, gin.H{"error": "only finished exams can be retaken"})
+			return
+		case parent.Type != "exam":
+			c.JSON(http.StatusConflict, gin.H{"error": "only regular exams can be retaken"})
+			return
+		}
+
+		review, _, err := buildReview(db, parent.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		qids := retakeQuestions(req.Mode, review, req.Seed)
+		if len(qids) == 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "no wrong or skipped questions to retake"})
+			return
+		}
+
+		exam := Exam{
+			ID:              uuid.New().String(),
+			Type:            "exam",
+			StartedAt:       time.Now(),
+			DurationSeconds: retakeDuration(*parent, len(review), len(qids)),
+			UserID:          parent.UserID,
+			BankID:          parent.BankID,
+			PassPercent:     parent.PassPercent,
+			Scoring:         parent.Scoring,
+			Delivery:        parent.Delivery,
+			AllowBack:       parent.AllowBack,
+			LockAnswered:    parent.LockAnswered,
+			MaxPauseSeconds: parent.MaxPauseSeconds,
+			ParentExamID:    &parent.ID,
+			RetakeMode:      req.Mode,
+		}
+		switch req.Mode {
+		case RetakeSame:
+			exam.Seed = parent.Seed // ten sam zestaw i kolejność
+		case RetakeShuffled:
+			exam.Seed = req.Seed
+		}
+		if parent.AssignmentID != nil {
+			// powtórka zadania od trenera to zwykły egzamin ćwiczeniowy
+			exam.MaxPauseSeconds = defaultMaxPauseSec
+		}
+		if err := createExam(db, &exam, qids); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+
+		resp := gin.H{
+			"examId":       exam.ID,
+			"parentExamId": parent.ID,
+			"mode":         exam.RetakeMode,
+			"bank":         exam.BankID,
+			"durationSec":  exam.DurationSeconds,
+			"scoring":      examScoring(exam),
+			"delivery":     examDelivery(exam),
+			"clock":        computeClock(exam, nil, time.Now()),
+		}
+		if examDelivery(exam) == DeliverySequential {
+			resp["allowBack"], resp["lockAnswered"] = exam.AllowBack, exam.LockAnswered
+			resp["questionCount"] = len(qids)
+		} else {
+			out, err := loadQuestionDTOs(db, qids)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+				return
+			}
+			resp["questions"] = out
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// retakeRoot idzie po ParentExamID do pierwszego egzaminu serii powtórek.
+func retakeRoot(db *gorm.DB, e Exam) (Exam, error) {
+	seen := map[string]bool{e.ID: true}
+	for e.ParentExamID != nil && !seen[*e.ParentExamID] {
+		var parent Exam
+		if err := db.First(&parent, "id = ?", *e.ParentExamID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return e, nil // rodzic usunięty: seria zaczyna się tutaj
+			}
+			return e, err
+		}
+		seen[parent.ID] = true
+		e = parent
+	}
+	return e, nil
+}
+
+// sameRetakeFamily mówi, czy oba egzaminy pochodzą z tego samego egzaminu źródłowego.
+func sameRetakeFamily(db *gorm.DB, a, b Exam) (bool, error) {
+	if a.ParentExamID == nil && b.ParentExamID == nil {
+		return false, nil
+	}
+	ra, err := retakeRoot(db, a)
+	if err != nil {
+		return false, err
+	}
+	rb, err := retakeRoot(db, b)
+	if err != nil {
+		return false, err
+	}
+	return ra.ID == rb.ID, nil
+}
+
+// retakeFamily zwraca ID egzaminu źródłowego i wszystkich jego powtórek (także pośrednich).
+func retakeFamily(db *gorm.DB, e Exam) ([]string, error) {
+	root, err := retakeRoot(db, e)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{root.ID}
+	frontier := []string{root.ID}
+	for len(frontier) > 0 {
+		var next []string
+		if err := db.Model(&Exam{}).Where("parent_exam_id IN ?", frontier).Pluck("id", &next).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, next...)
+		frontier = next
+	}
+	return ids, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRetakeQuestions(t *testing.T) {
+	review := []ReviewRow{
+		{QuestionID: "q1", WasCorrect: true},
+		{QuestionID: "q2"}, // błędna
+		{QuestionID: "q3", WasCorrect: true},
+		{QuestionID: "q4", Selected: []string{}}, // pominięta
+	}
+	if got := retakeQuestions(RetakeSame, review, nil); !reflect.DeepEqual(got, []string{"q1", "q2", "q3", "q4"}) {
+		t.Errorf("same: %v", got)
+	}
+	if got := retakeQuestions(RetakeWrong, review, nil); !reflect.DeepEqual(got, []string{"q2", "q4"}) {
+		t.Errorf("wrong: %v", got)
+	}
+	seed := int64(7)
+	shuffled := retakeQuestions(RetakeShuffled, review, &seed)
+	if again := retakeQuestions(RetakeShuffled, review, &seed); !reflect.DeepEqual(shuffled, again) {
+		t.Errorf("shuffled with seed not reproducible: %v vs %v", shuffled, again)
+	}
+	sort.Strings(shuffled)
+	if !reflect.DeepEqual(shuffled, []string{"q1", "q2", "q3", "q4"}) {
+		t.Errorf("shuffled changed the question set: %v", shuffled)
+	}
+}
+
+func TestRetakeDuration(t *testing.T) {
+	parent := Exam{DurationSeconds: 10800}
+	tests := []struct{ total, n, want int }{
+		{80, 80, 10800},
+		{80, 20, 2700},
+		{80, 1, 180}, // 135 s w górę do pełnej minuty
+		{0, 0, 10800},
+	}
+	for _, tt := range tests {
+		if got := retakeDuration(parent, tt.total, tt.n); got != tt.want {
+			t.Errorf("retakeDuration(%d of %d) = %d, want %d", tt.n, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestRetakeFamily(t *testing.T) {
+	db := newTestDB(t)
+	ptr := func(s string) *string { return &s }
+	db.Create(&Exam{ID: "root", Type: "exam"})
+	db.Create(&Exam{ID: "r1", Type: "exam", ParentExamID: ptr("root")})
+	db.Create(&Exam{ID: "r2", Type: "exam", ParentExamID: ptr("r1")})
+	db.Create(&Exam{ID: "r3", Type: "exam", ParentExamID: ptr("root")})
+	db.Create(&Exam{ID: "other", Type: "exam"})
+
+	var r2 Exam
+	db.First(&r2, "id = ?", "r2")
+	ids, err := retakeFamily(db, r2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"r1", "r2", "r3", "root"}) {
+		t.Errorf("family = %v", ids)
+	}
+
+	var r3, other Exam
+	db.First(&r3, "id = ?", "r3")
+	db.First(&other, "id = ?", "other")
+	if ok, _ := sameRetakeFamily(db, r2, r3); !ok {
+		t.Error("r2 and r3 share the root exam")
+	}
+	if ok, _ := sameRetakeFamily(db, r2, other); ok {
+		t.Error("unrelated exam reported as retake")
+	}
+}
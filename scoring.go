@@ -0,0 +1,79 @@
+package main
+
+// Strategie punktacji egzaminu (Exam.Scoring). Punkty za pytanie mieszczą się w [-1, 1];
+// IsCorrect odpowiedzi zawsze znaczy "dokładnie poprawny zestaw" (statystyki, review).
+const (
+	ScoringAllOrNothing   = "all_or_nothing"  // 1 za dokładnie poprawny zestaw, inaczej 0
+	ScoringPartial        = "partial"         // ułamek trafionych poprawnych opcji, złe wybory bez kary
+	ScoringPartialPenalty = "partial_penalty" // jak partial, minus ułamek wybranych złych opcji (min. 0)
+	ScoringNegative       = "negative"        // 1 za poprawny zestaw, -1/(k-1) za błędny, 0 bez wyboru
+)
+
+var scoringStrategies = []string{ScoringAllOrNothing, ScoringPartial, ScoringPartialPenalty, ScoringNegative}
+
+// scoreAnswer zwraca punkty za odpowiedź; optionCount to liczba wszystkich opcji pytania.
+func scoreAnswer(strategy string, selected, correct []string, optionCount int) float64 {
+	exact := isCorrectAllOrNothing(selected, correct)
+	if len(correct) == 0 {
+		return 0
+	}
+	picked := map[string]bool{}
+	for _, k := range selected {
+		picked[k] = true
+	}
+	hits := 0
+	for _, k := range correct {
+		if picked[k] {
+			hits++
+		}
+	}
+	wrong := len(picked) - hits
+
+	switch strategy {
+	case ScoringPartial:
+		return float64(hits) / float64(len(correct))
+	case ScoringPartialPenalty:
+		p := float64(hits) / float64(len(correct))
+		if incorrect := optionCount - len(correct); incorrect > 0 {
+			p -= float64(wrong) / float64(incorrect)
+		}
+		if p < 0 {
+			return 0
+		}
+		return p
+	case ScoringNegative:
+		switch {
+		case exact:
+			return 1
+		case len(picked) == 0 || optionCount < 2:
+			return 0
+		default:
+			// zgadywanie wśród k opcji ma wartość oczekiwaną 0
+			return -1 / float64(optionCount-1)
+		}
+	default:
+		if exact {
+			return 1
+		}
+		return 0
+	}
+}
+
+// answerPoints to punkty zapisanej odpowiedzi; starsze odpowiedzi (bez Points) liczymy all-or-nothing.
+func answerPoints(a Answer) float64 {
+	if a.Points != nil {
+		return *a.Points
+	}
+	if a.IsCorrect {
+		return 1
+	}
+	return 0
+}
+
+// examScoring zwraca strategię egzaminu; pusta (egzaminy sprzed strategii) = all-or-nothing.
+func examScoring(e Exam) string {
+	if e.Scoring == "" {
+		return ScoringAllOrNothing
+	}
+	return e.Scoring
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScoreAnswer(t *testing.T) {
+	correct := []string{"a", "b"} // 2 z 4 opcji poprawne
+	tests := []struct {
+		strategy string
+		selected []string
+		want     float64
+	}{
+		{ScoringAllOrNothing, []string{"a", "b"}, 1},
+		{ScoringAllOrNothing, []string{"a"}, 0},
+		{ScoringPartial, []string{"a"}, 0.5},
+		{ScoringPartial, []string{"a", "c"}, 0.5},
+		{ScoringPartialPenalty, []string{"a", "c"}, 0},
+		{ScoringPartialPenalty, []string{"a", "b", "c"}, 0.5},
+		{ScoringPartialPenalty, []string{"c", "d"}, 0},
+		{ScoringNegative, []string{"a", "b"}, 1},
+		{ScoringNegative, []string{"a"}, -1.0 / 3},
+		{ScoringNegative, nil, 0},
+		{"", []string{"a", "b"}, 1}, // egzaminy sprzed strategii
+	}
+	for _, tt := range tests {
+		got := scoreAnswer(tt.strategy, tt.selected, correct, 4)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s %v = %v, want %v", tt.strategy, tt.selected, got, tt.want)
+		}
+	}
+}
+
+func TestComputeExamScoreUsesPoints(t *testing.T) {
+	db := newTestDB(t)
+	half, neg := 0.5, -1.0
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: "[]", Points: &half})
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q2", SelectedRaw: "[]", IsCorrect: true}) // bez Points: 1
+	score, correct, wrong, err := computeExamScore(db, "e1")
+	if err != nil || score != 75 || correct != 1 || wrong != 1 {
+		t.Errorf("score=%v correct=%d wrong=%d err=%v", score, correct, wrong, err)
+	}
+
+	db.Create(&Answer{ExamID: "e2", QuestionID: "q1", SelectedRaw: "[]", Points: &neg})
+	if score, _, _, _ := computeExamScore(db, "e2"); score != 0 {
+		t.Errorf("negative marking score = %v, want clamped to 0", score)
+	}
+}
+
+func TestComputeExamScoreCountsSkippedQuestions(t *testing.T) {
+	db := newTestDB(t)
+	one := 1.0
+	for i, q := range []string{"q1", "q2", "q3", "q4"} {
+		db.Create(&ExamQuestion{ExamID: "e1", QuestionID: q, Position: i + 1})
+	}
+	// ujemne punktowanie: 2 poprawne, q3 pominięte, q4 bez odpowiedzi
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: `["a"]`, IsCorrect: true, Points: &one})
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q2", SelectedRaw: `["a"]`, IsCorrect: true, Points: &one})
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q3", SelectedRaw: "[]", Points: new(float64)})
+	score, correct, wrong, err := computeExamScore(db, "e1")
+	if err != nil || score != 50 || correct != 2 || wrong != 1 {
+		t.Errorf("score=%v correct=%d wrong=%d err=%v, want 50%% of all 4 questions", score, correct, wrong, err)
+	}
+}
+
+func TestDedupeExamAnswers(t *testing.T) {
+	db := newTestDB(t)
+	// baza sprzed idx_answer_exam_question: kilka odpowiedzi na to samo pytanie
+	if err := db.Migrator().DropIndex(&Answer{}, "idx_answer_exam_question"); err != nil {
+		t.Fatal(err)
+	}
+	ms := func(v int64) *int64 { return &v }
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: `["b"]`, TimeSpentMs: ms(1000)})
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: `["a"]`, IsCorrect: true, TimeSpentMs: ms(2000)})
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q2", SelectedRaw: `["a"]`})
+
+	if err := AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	var answers []Answer
+	db.Order("question_id").Find(&answers)
+	if len(answers) != 2 || answers[0].SelectedRaw != `["a"]` || answers[0].TimeSpentMs == nil || *answers[0].TimeSpentMs != 3000 {
+		t.Fatalf("answers = %+v, want the latest q1 answer with 3000 ms", answers)
+	}
+	if err := db.Create(&Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: "[]"}).Error; err == nil {
+		t.Error("second answer to the same question accepted")
+	}
+}
+
+func TestValidateSelection(t *testing.T) {
+	opts := []Option{{OptionKey: "a", IsCorrect: true}, {OptionKey: "b", IsCorrect: true}, {OptionKey: "c"}, {OptionKey: "d"}}
+	for _, ok := range [][]string{nil, {"a"}, {"c", "d"}} {
+		if err := validateSelection(ok, opts); err != nil {
+			t.Errorf("%v rejected: %+v", ok, err)
+		}
+	}
+	err := validateSelection([]string{"a", "z", "a", "c", "d"}, opts)
+	if err == nil || err.SelectCount != 2 {
+		t.Fatalf("err = %+v", err)
+	}
+	if len(err.UnknownKeys) != 1 || err.UnknownKeys[0] != "z" {
+		t.Errorf("unknown = %v", err.UnknownKeys)
+	}
+	if len(err.DuplicateKeys) != 1 || err.DuplicateKeys[0] != "a" {
+		t.Errorf("duplicates = %v", err.DuplicateKeys)
+	}
+	if len(err.SurplusKeys) != 1 || err.SurplusKeys[0] != "d" {
+		t.Errorf("surplus = %v", err.SurplusKeys)
+	}
+
+	single := []Option{{OptionKey: "a", IsCorrect: true}, {OptionKey: "b"}}
+	if err := validateSelection([]string{"a", "b"}, single); err == nil || err.SelectCount != 1 {
+		t.Errorf("two keys for a single-select question accepted: %+v", err)
+	}
+}
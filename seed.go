@@ -3,7 +3,10 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"gorm.io/gorm"
@@ -36,24 +39,59 @@ type QInput struct {
 	CorrectOptionIds []string           `json:"correctOptionIds"`
 }
 
+// BankInput to opcjonalny nagłówek pliku z pytaniami:
+// { "bank": { "id": "commerce-dev", "name": "...", "passPercent": 61 }, "questions": [ ... ] }
+type BankInput struct {
+	ID                   string  `json:"id"`
+	Name                 string  `json:"name"`
+	Description          string  `json:"description"`
+	Default              bool    `json:"default"`
+	DefaultQuestionCount int     `json:"defaultQuestionCount"`
+	DefaultDurationSec   int     `json:"defaultDurationSec"`
+	PassPercent          float64 `json:"passPercent"`
+}
+
 // ==== Seeder ====
 
-func SeedFromJSON(db *gorm.DB, path string) error {
+// parseSeedFile reads one bank file. Bank metadata is optional; without it the
+// bank ID is the file name (data/cx.json -> "cx").
+func parseSeedFile(path string) (BankInput, []QInput, error) {
 	raw, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return BankInput{}, nil, err
 	}
 
-	// Accept either: [ ... ] or { "questions": [ ... ] }
+	// Accept either: [ ... ] or { "bank": {...}, "questions": [ ... ] }
 	var wrapper struct {
-		Questions []QInput `json:"questions"`
+		Bank      BankInput `json:"bank"`
+		Questions []QInput  `json:"questions"`
 	}
 	var arr []QInput
 
 	if err := json.Unmarshal(raw, &wrapper); err == nil && len(wrapper.Questions) > 0 {
 		arr = wrapper.Questions
 	} else if err := json.Unmarshal(raw, &arr); err != nil {
-		return fmt.Errorf("json parse: %w", err)
+		return BankInput{}, nil, fmt.Errorf("json parse: %w", err)
+	}
+
+	bank := wrapper.Bank
+	if bank.ID == "" {
+		bank.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if !validBankID(bank.ID) {
+		return BankInput{}, nil, fmt.Errorf("bad bank id %q (use a-z, 0-9, '-')", bank.ID)
+	}
+	if bank.Name == "" {
+		bank.Name = bank.ID
+	}
+	if bank.DefaultQuestionCount <= 0 {
+		bank.DefaultQuestionCount = 80
+	}
+	if bank.DefaultDurationSec <= 0 {
+		bank.DefaultDurationSec = 3 * 60 * 60
+	}
+	if bank.PassPercent <= 0 {
+		bank.PassPercent = passThreshold
 	}
 
 	// Basic validation: unique question IDs
@@ -66,71 +104,205 @@ func SeedFromJSON(db *gorm.DB, path string) error {
 		seen[q.ID] = true
 	}
 	if len(dups) > 0 {
-		return fmt.Errorf("duplicate question IDs in JSON: %v", dups)
+		return BankInput{}, nil, fmt.Errorf("duplicate question IDs in JSON: %v", dups)
 	}
+	return bank, arr, nil
+}
 
-	// Seed transactionally
-	return db.Transaction(func(tx *gorm.DB) error {
-		for _, in := range arr {
-			q := Question{
-				ID:          in.ID,
-				TextEN:      in.QuestionText,
-				MultiSelect: in.MultiSelect,
-				Version:     1,
-			}
-			if err := tx.Create(&q).Error; err != nil {
-				return err
-			}
+// SeedBanks loads every *.json in dir as a question bank. Bank metadata is
+// refreshed on every start; questions are inserted only into empty banks.
+// Questions from before banks existed are moved into the default bank first.
+func SeedBanks(db *gorm.DB, dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
 
-			// Build set of correct option keys (lowercased "a".."d")
-			correctSet := map[string]bool{}
-			for _, k := range in.CorrectOptionIds {
-				correctSet[stringsLower(k)] = true
-			}
+	type bankFile struct {
+		path      string
+		bankID    string
+		questions []QInput
+	}
+	var files []bankFile
+	var defaultBank string
+	for _, path := range paths {
+		in, questions, err := parseSeedFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		bank := QuestionBank{
+			ID:                   in.ID,
+			Name:                 in.Name,
+			Description:          in.Description,
+			IsDefault:            in.Default,
+			DefaultQuestionCount: in.DefaultQuestionCount,
+			DefaultDurationSec:   in.DefaultDurationSec,
+			PassPercent:          in.PassPercent,
+			SourceFile:           filepath.Base(path),
+		}
+		if err := db.Save(&bank).Error; err != nil {
+			return err
+		}
+		if in.Default && defaultBank == "" {
+			defaultBank = bank.ID
+		}
+		files = append(files, bankFile{path: path, bankID: bank.ID, questions: questions})
+	}
 
-			// Insert options
-			for _, o := range in.Options {
-				ok := correctSet[stringsLower(o.ID)]
-				option := Option{
-					QuestionID: q.ID,
-					OptionKey:  stringsLower(o.ID),
-					TextEN:     o.Text,
-					IsCorrect:  ok,
-				}
-				if err := tx.Create(&option).Error; err != nil {
+	// dokładnie jeden bank domyślny: z pliku albo pierwszy alfabetycznie
+	if defaultBank == "" {
+		var first QuestionBank
+		if err := db.Order("id").First(&first).Error; err != nil {
+			return nil // brak banków: pusta baza
+		}
+		defaultBank = first.ID
+	}
+	if err := db.Model(&QuestionBank{}).Where("id <> ?", defaultBank).Update("is_default", false).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&QuestionBank{}).Where("id = ?", defaultBank).Update("is_default", true).Error; err != nil {
+		return err
+	}
+	if err := migrateLegacyQuestions(db, defaultBank); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		var count int64
+		if err := db.Model(&Question{}).Where("bank_id = ?", f.bankID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		// Seed transactionally
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, q := range f.questions {
+				if _, err := insertQuestion(tx, f.bankID, q, nil); err != nil {
 					return err
 				}
 			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+		log.Printf("Seeded %d questions into bank %q from %s", len(f.questions), f.bankID, f.path)
+	}
+	return nil
+}
 
-			// Insert explanations (EN)
-			for _, e := range in.OptionsExplanation.EN {
-				ex := Explanation{
-					QuestionID: q.ID,
-					OptionKey:  stringsLower(e.ID),
-					Lang:       "en",
-					Text:       e.Text,
-					URL:        strings.TrimSpace(e.URL),
-				}
-				if err := tx.Create(&ex).Error; err != nil {
+// questionRefColumns to kolumny wskazujące na questions.id (przepisywane przy migracji ID).
+var questionRefColumns = [][2]string{
+	{"options", "question_id"},
+	{"explanations", "question_id"},
+	{"exam_questions", "question_id"},
+	{"answers", "question_id"},
+	{"assignment_questions", "question_id"},
+	{"question_reports", "question_id"},
+	{"comments", "question_id"},
+	{"question_proposals", "published_question_id"},
+}
+
+// migrateLegacyQuestions przenosi pytania bez banku (baza sprzed banków) do
+// bankID: nadaje prefiks ID we wszystkich tabelach i przypisuje stare egzaminy.
+func migrateLegacyQuestions(db *gorm.DB, bankID string) error {
+	var n int64
+	if err := db.Model(&Question{}).Where("bank_id = '' OR bank_id IS NULL").Count(&n).Error; err != nil {
+		return err
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if n > 0 {
+			legacy := tx.Model(&Question{}).Select("id").Where("bank_id = '' OR bank_id IS NULL")
+			prefix := bankID + questionIDSep
+			for _, ref := range questionRefColumns {
+				if err := tx.Table(ref[0]).Where(ref[1]+" IN (?)", legacy).
+					Update(ref[1], gorm.Expr("? || "+ref[1], prefix)).Error; err != nil {
 					return err
 				}
 			}
-			// Insert explanations (PL)
-			for _, e := range in.OptionsExplanation.PL {
-				ex := Explanation{
-					QuestionID: q.ID,
-					OptionKey:  stringsLower(e.ID),
-					Lang:       "pl",
-					Text:       e.Text,
-					URL:        strings.TrimSpace(e.URL),
-				}
-				if err := tx.Create(&ex).Error; err != nil {
-					return err
-				}
+			if err := tx.Model(&Question{}).Where("bank_id = '' OR bank_id IS NULL").
+				Updates(map[string]interface{}{"id": gorm.Expr("? || id", prefix), "bank_id": bankID}).Error; err != nil {
+				return err
+			}
+		}
+		for _, t := range []interface{}{&Exam{}, &Assignment{}, &QuestionProposal{}} {
+			if err := tx.Model(t).Where("bank_id = '' OR bank_id IS NULL").Update("bank_id", bankID).Error; err != nil {
+				return err
 			}
 		}
 		return nil
 	})
+	if err == nil && n > 0 {
+		log.Printf("Moved %d legacy questions into bank %q", n, bankID)
+	}
+	return err
+}
+
+// insertQuestion inserts one question with its options and EN/PL explanations.
+// in.ID is the ID within the bank; the stored ID is namespaced ("bank:001") and returned.
+// authorID is set for community-contributed questions (nil for seeded ones).
+func insertQuestion(tx *gorm.DB, bankID string, in QInput, authorID *uint) (string, error) {
+	q := Question{
+		ID:          bankQuestionID(bankID, in.ID),
+		BankID:      bankID,
+		TextEN:      in.QuestionText,
+		MultiSelect: in.MultiSelect,
+		Version:     1,
+		AuthorID:    authorID,
+	}
+	if err := tx.Create(&q).Error; err != nil {
+		return "", err
+	}
+
+	// Build set of correct option keys (lowercased "a".."d")
+	correctSet := map[string]bool{}
+	for _, k := range in.CorrectOptionIds {
+		correctSet[stringsLower(k)] = true
+	}
+
+	// Insert options
+	for _, o := range in.Options {
+		ok := correctSet[stringsLower(o.ID)]
+		option := Option{
+			QuestionID: q.ID,
+			OptionKey:  stringsLower(o.ID),
+			TextEN:     o.Text,
+			IsCorrect:  ok,
+		}
+		if err := tx.Create(&option).Error; err != nil {
+			return "", err
+		}
+	}
+
+	// Insert explanations (EN)
+	for _, e := range in.OptionsExplanation.EN {
+		ex := Explanation{
+			QuestionID: q.ID,
+			OptionKey:  stringsLower(e.ID),
+			Lang:       "en",
+			Text:       e.Text,
+			URL:        strings.TrimSpace(e.URL),
+		}
+		if err := tx.Create(&ex).Error; err != nil {
+			return "", err
+		}
+	}
+	// Insert explanations (PL)
+	for _, e := range in.OptionsExplanation.PL {
+		ex := Explanation{
+			QuestionID: q.ID,
+			OptionKey:  stringsLower(e.ID),
+			Lang:       "pl",
+			Text:       e.Text,
+			URL:        strings.TrimSpace(e.URL),
+		}
+		if err := tx.Create(&ex).Error; err != nil {
+			return "", err
+		}
+	}
+	return q.ID, nil
 }
 
 // stringsLower normalizes option ids like "A".."D" to lowercase.
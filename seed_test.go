@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const seedQuestion = `{"id": "001", "questionText": "Q?", "options": [{"id": "A", "text": "yes"}, {"id": "b", "text": "no"}],
+	"correctOptionIds": ["a"], "optionsExplanation": {"en": [{"id": "a", "text": "because"}]}}`
+
+func writeSeedFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSeedBanks(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeSeedFile(t, dir, "questions.json", `{"bank": {"id": "dev", "name": "Developer", "default": true, "passPercent": 70}, "questions": [`+seedQuestion+`]}`)
+	writeSeedFile(t, dir, "cx.json", `[`+seedQuestion+`]`)
+
+	if err := SeedBanks(db, dir); err != nil {
+		t.Fatal(err)
+	}
+	var banks []QuestionBank
+	db.Order("id").Find(&banks)
+	if len(banks) != 2 || banks[0].ID != "cx" || banks[1].ID != "dev" {
+		t.Fatalf("banks = %+v", banks)
+	}
+	if banks[0].IsDefault || !banks[1].IsDefault {
+		t.Errorf("default bank: cx=%v dev=%v, want dev", banks[0].IsDefault, banks[1].IsDefault)
+	}
+	if banks[0].DefaultQuestionCount != 80 || banks[0].PassPercent != passThreshold || banks[1].PassPercent != 70 {
+		t.Errorf("bank defaults not applied: %+v", banks)
+	}
+
+	var ids []string
+	db.Model(&Question{}).Order("id").Pluck("id", &ids)
+	if len(ids) != 2 || ids[0] != "cx:001" || ids[1] != "dev:001" {
+		t.Errorf("question ids = %v, want namespaced per bank", ids)
+	}
+	var opt Option
+	db.First(&opt, "question_id = ? AND option_key = ?", "dev:001", "a")
+	if !opt.IsCorrect {
+		t.Error("option a of dev:001 should be correct")
+	}
+
+	// ponowny start nie dubluje pytań
+	if err := SeedBanks(db, dir); err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	db.Model(&Question{}).Count(&n)
+	if n != 2 {
+		t.Errorf("questions after reseed = %d, want 2", n)
+	}
+}
+
+func TestSeedBanksMigratesLegacyQuestions(t *testing.T) {
+	db := newTestDB(t)
+	// baza sprzed banków: pytania bez prefiksu i egzamin z odpowiedzią
+	db.Create(&Question{ID: "001", TextEN: "old"})
+	db.Create(&Option{QuestionID: "001", OptionKey: "a", TextEN: "yes", IsCorrect: true})
+	db.Create(&Exam{ID: "e1", Type: "exam", DurationSeconds: 60})
+	db.Create(&ExamQuestion{ExamID: "e1", QuestionID: "001", Position: 1})
+	db.Create(&Answer{ExamID: "e1", QuestionID: "001", SelectedRaw: `["a"]`, IsCorrect: true})
+
+	dir := t.TempDir()
+	writeSeedFile(t, dir, "questions.json", `{"bank": {"id": "dev"}, "questions": [`+seedQuestion+`]}`)
+	if err := SeedBanks(db, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	var q Question
+	if err := db.First(&q, "id = ?", "dev:001").Error; err != nil {
+		t.Fatalf("legacy question not migrated: %v", err)
+	}
+	if q.TextEN != "old" || q.BankID != "dev" {
+		t.Errorf("migrated question = %+v, want existing row kept (not reseeded)", q)
+	}
+	for _, ref := range []struct {
+		model interface{}
+		name  string
+	}{{&Option{}, "options"}, {&ExamQuestion{}, "exam_questions"}, {&Answer{}, "answers"}} {
+		var n int64
+		db.Model(ref.model).Where("question_id = ?", "dev:001").Count(&n)
+		if n != 1 {
+			t.Errorf("%s not rewritten to dev:001", ref.name)
+		}
+	}
+	var e Exam
+	db.First(&e, "id = ?", "e1")
+	if e.BankID != "dev" {
+		t.Errorf("legacy exam bank = %q, want dev", e.BankID)
+	}
+}
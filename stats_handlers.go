@@ -10,8 +10,12 @@ import (
 )
 
 type StatsResponse struct {
+	Bank               string             `json:"bank,omitempty"` // pusty = wszystkie banki
 	TotalExams         int64              `json:"totalExams"`
 	CompletedExams     int64              `json:"completedExams"`
+	ExpiredExams       int64              `json:"expiredExams"`   // czas minął bez finish
+	AbandonedExams     int64              `json:"abandonedExams"` // nie wliczane nigdzie indziej
+	Drills             DrillStats         `json:"drills"`         // sesje drill poza licznikami egzaminów
 	AverageScore       *float64           `json:"averageScore,omitempty"`
 	TotalAnswers       int64              `json:"totalAnswers"`
 	CorrectAnswers     int64              `json:"correctAnswers"`
@@ -21,6 +25,16 @@ type StatsResponse struct {
 	AccuracyLast30d    *float64           `json:"accuracyLast30d,omitempty"`
 	AccuracyByTag      map[string]float64 `json:"accuracyByTag,omitempty"` // tag -> percent
 	AnsweredByTag      map[string]int64   `json:"answeredByTag,omitempty"` // tag -> count
+	AvgTimeMs          *float64           `json:"avgTimeMs,omitempty"` // średni czas odpowiedzi (z pomiarem)
+	AvgTimeByTagMs     map[string]float64 `json:"avgTimeByTagMs,omitempty"` // tag -> ms
+}
+
+type DrillStats struct {
+	Sessions        int64    `json:"sessions"`
+	Completed       int64    `json:"completed"`
+	Answers         int64    `json:"answers"`
+	Correct         int64    `json:"correct"`
+	AccuracyPercent *float64 `json:"accuracyPercent,omitempty"`
 }
 
 func Stats(db *gorm.DB) gin.HandlerFunc {
@@ -33,107 +47,236 @@ func Stats(db *gorm.DB) gin.HandlerFunc {
 		}
 		uid := v.(uint)
 
-		resp := StatsResponse{
-			AccuracyByTag: make(map[string]float64),
-			AnsweredByTag: make(map[string]int64),
-		}
-
-		// exams counts
-		if err := db.Model(&Exam{}).Where("user_id = ?", uid).Count(&resp.TotalExams).Error; err != nil {
+		resp, err := computeStats(db, uid, c.Query("bank"))
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 			return
 		}
-		if err := db.Model(&Exam{}).Where("user_id = ? AND finished_at IS NOT NULL", uid).Count(&resp.CompletedExams).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
-			return
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// computeStats liczy agregaty dla usera (wspólne dla /stats i eksportu danych).
+// bank != "" zawęża wszystko do egzaminów z tego banku.
+func computeStats(db *gorm.DB, uid uint, bank string) (*StatsResponse, error) {
+	resp := &StatsResponse{
+		Bank:          bank,
+		AccuracyByTag:  make(map[string]float64),
+		AnsweredByTag:  make(map[string]int64),
+		AvgTimeByTagMs: make(map[string]float64),
+	}
+	if err := expireExams(db, uid, time.Now()); err != nil {
+		return nil, err
+	}
+	// egzaminy usera bez porzuconych (opcjonalnie tylko z banku); alias e dla joinów z answers
+	exams := func() *gorm.DB {
+		tx := db.Table("exams e").Where("e.user_id = ? AND e.status <> ?", uid, ExamStatusAbandoned)
+		if bank != "" {
+			tx = tx.Where("e.bank_id = ?", bank)
 		}
-		// average score (only finished exams)
-		type RowAvg struct{ Avg *float64 }
-		var rowAvg RowAvg
-		_ = db.Table("exams").Where("user_id = ? AND score_percent IS NOT NULL", uid).
-			Select("AVG(score_percent) as avg").Scan(&rowAvg).Error
-		resp.AverageScore = rowAvg.Avg
-
-		// overall answers & correct (join answers->exams to filter by user)
-		type RowCnt struct{ C int64 }
-		var total RowCnt
-		_ = db.Table("answers a").
-			Joins("JOIN exams e ON e.id = a.exam_id").
-			Where("e.user_id = ?", uid).
-			Select("COUNT(*) as c").Scan(&total).Error
-		resp.TotalAnswers = total.C
-
-		var corr RowCnt
-		_ = db.Table("answers a").
-			Joins("JOIN exams e ON e.id = a.exam_id").
-			Where("e.user_id = ? AND a.is_correct = 1", uid).
-			Select("COUNT(*) as c").Scan(&corr).Error
-		resp.CorrectAnswers = corr.C
-
-		if resp.TotalAnswers > 0 {
-			acc := float64(resp.CorrectAnswers) * 100.0 / float64(resp.TotalAnswers)
-			resp.AccuracyOverall = &acc
+		return tx
+	}
+	// egzaminy bez sesji drill (te liczone osobno w resp.Drills)
+	mockExams := func() *gorm.DB {
+		return exams().Where("e.type <> ?", ExamTypeDrill)
+	}
+	answers := func() *gorm.DB {
+		tx := db.Table("answers a").Joins("JOIN exams e ON e.id = a.exam_id").Where("e.user_id = ? AND e.status <> ?", uid, ExamStatusAbandoned)
+		if bank != "" {
+			tx = tx.Where("e.bank_id = ?", bank)
 		}
+		return tx
+	}
+	// odpowiedzi bez drilli (te w resp.Drills)
+	mockAnswers := func() *gorm.DB {
+		return answers().Where("e.type <> ?", ExamTypeDrill)
+	}
+
+	// exams counts
+	if err := mockExams().Count(&resp.TotalExams).Error; err != nil {
+		return nil, err
+	}
+	if err := mockExams().Where("e.finished_at IS NOT NULL").Count(&resp.CompletedExams).Error; err != nil {
+		return nil, err
+	}
+	if err := mockExams().Where("e.status = ?", ExamStatusExpired).Count(&resp.ExpiredExams).Error; err != nil {
+		return nil, err
+	}
+	abandoned := db.Model(&Exam{}).Where("user_id = ? AND status = ? AND type <> ?", uid, ExamStatusAbandoned, ExamTypeDrill)
+	if bank != "" {
+		abandoned = abandoned.Where("bank_id = ?", bank)
+	}
+	if err := abandoned.Count(&resp.AbandonedExams).Error; err != nil {
+		return nil, err
+	}
+	// average score (only finished exams)
+	type RowAvg struct{ Avg *float64 }
+	var rowAvg RowAvg
+	_ = mockExams().Where("e.score_percent IS NOT NULL").
+		Select("AVG(e.score_percent) as avg").Scan(&rowAvg).Error
+	resp.AverageScore = rowAvg.Avg
+
+	// drills: osobne liczniki
+	if err := exams().Where("e.type = ?", ExamTypeDrill).Count(&resp.Drills.Sessions).Error; err != nil {
+		return nil, err
+	}
+	if err := exams().Where("e.type = ? AND e.finished_at IS NOT NULL", ExamTypeDrill).Count(&resp.Drills.Completed).Error; err != nil {
+		return nil, err
+	}
+	if err := answers().Where("e.type = ?", ExamTypeDrill).Count(&resp.Drills.Answers).Error; err != nil {
+		return nil, err
+	}
+	if err := answers().Where("e.type = ? AND a.is_correct = 1", ExamTypeDrill).Count(&resp.Drills.Correct).Error; err != nil {
+		return nil, err
+	}
+	if resp.Drills.Answers > 0 {
+		acc := float64(resp.Drills.Correct) * 100 / float64(resp.Drills.Answers)
+		resp.Drills.AccuracyPercent = &acc
+	}
+
+	// overall answers & correct (join answers->exams to filter by user)
+	type RowCnt struct{ C int64 }
+	var total RowCnt
+	_ = mockAnswers().Select("COUNT(*) as c").Scan(&total).Error
+	resp.TotalAnswers = total.C
+
+	var corr RowCnt
+	_ = mockAnswers().Where("a.is_correct = 1").
+		Select("COUNT(*) as c").Scan(&corr).Error
+	resp.CorrectAnswers = corr.C
+
+	if resp.TotalAnswers > 0 {
+		acc := float64(resp.CorrectAnswers) * 100.0 / float64(resp.TotalAnswers)
+		resp.AccuracyOverall = &acc
+	}
+
+	// last 30 days
+	since := time.Now().Add(-30 * 24 * time.Hour)
+	var tot30 RowCnt
+	_ = mockAnswers().Where("a.answered_at >= ?", since).
+		Select("COUNT(*) as c").Scan(&tot30).Error
+	resp.AnswersLast30d = tot30.C
+
+	var cor30 RowCnt
+	_ = mockAnswers().Where("a.answered_at >= ? AND a.is_correct = 1", since).
+		Select("COUNT(*) as c").Scan(&cor30).Error
+	resp.CorrectLast30d = cor30.C
+
+	if resp.AnswersLast30d > 0 {
+		acc30 := float64(resp.CorrectLast30d) * 100.0 / float64(resp.AnswersLast30d)
+		resp.AccuracyLast30d = &acc30
+	}
+
+	// average time per answer (AVG pomija odpowiedzi bez pomiaru)
+	_ = mockAnswers().Select("AVG(a.time_spent_ms) as avg").Scan(&rowAvg).Error
+	resp.AvgTimeMs = rowAvg.Avg
 
-		// last 30 days
-		since := time.Now().Add(-30 * 24 * time.Hour)
-		var tot30 RowCnt
-		_ = db.Table("answers a").
-			Joins("JOIN exams e ON e.id = a.exam_id").
-			Where("e.user_id = ? AND a.answered_at >= ?", uid, since).
-			Select("COUNT(*) as c").Scan(&tot30).Error
-		resp.AnswersLast30d = tot30.C
-
-		var cor30 RowCnt
-		_ = db.Table("answers a").
-			Joins("JOIN exams e ON e.id = a.exam_id").
-			Where("e.user_id = ? AND a.answered_at >= ? AND a.is_correct = 1", uid, since).
-			Select("COUNT(*) as c").Scan(&cor30).Error
-		resp.CorrectLast30d = cor30.C
-
-		if resp.AnswersLast30d > 0 {
-			acc30 := float64(resp.CorrectLast30d) * 100.0 / float64(resp.AnswersLast30d)
-			resp.AccuracyLast30d = &acc30
+	// accuracy per tag (CSV in questions.Tags)
+	byUser, err := countAnswersByTag(db, []uint{uid}, bank)
+	if err != nil {
+		return nil, err
+	}
+	for tag, tc := range byUser[uid] {
+		resp.AnsweredByTag[tag] = tc.Total
+		if acc := tc.Accuracy(); acc != nil {
+			resp.AccuracyByTag[tag] = *acc
+		}
+		if avg := tc.AvgTimeMs(); avg != nil {
+			resp.AvgTimeByTagMs[tag] = *avg
 		}
+	}
+
+	return resp, nil
+}
+
+// answerCount to licznik odpowiedzi (wszystkie / poprawne), np. dla jednego tagu.
+type answerCount struct {
+	Total   int64
+	Correct int64
+	Timed   int64 // odpowiedzi z pomiarem czasu
+	TimeMs  int64
+}
+
+// Accuracy zwraca procent poprawnych albo nil, gdy brak odpowiedzi.
+func (t answerCount) Accuracy() *float64 {
+	if t.Total == 0 {
+		return nil
+	}
+	acc := float64(t.Correct) * 100.0 / float64(t.Total)
+	return &acc
+}
 
-		// accuracy per tag (CSV in questions.Tags)
-		// Load answers + their questions' tags, then aggregate in Go.
-		type AnsJoin struct {
-			IsCorrect  bool
-			Tags       *string // CSV or JSON-ish string; we treat it as CSV "OMS,SOLR"
+// AvgTimeMs zwraca średni czas odpowiedzi albo nil, gdy brak pomiarów.
+func (t answerCount) AvgTimeMs() *float64 {
+	if t.Timed == 0 {
+		return nil
+	}
+	avg := float64(t.TimeMs) / float64(t.Timed)
+	return &avg
+}
+
+// splitTags dzieli CSV z questions.Tags (np. "OMS, Backoffice") na unikalne tagi.
+func splitTags(raw *string) []string {
+	if raw == nil || *raw == "" {
+		return nil
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, p := range strings.Split(*raw, ",") {
+		tag := strings.TrimSpace(p)
+		if tag == "" || seen[tag] {
+			continue
 		}
-		var rows []AnsJoin
-		_ = db.Table("answers a").
-			Select("a.is_correct as is_correct, q.tags as tags").
-			Joins("JOIN exams e ON e.id = a.exam_id").
-			Joins("JOIN questions q ON q.id = a.question_id").
-			Where("e.user_id = ?", uid).
-			Scan(&rows).Error
-
-		tagTotals := map[string]int64{}
-		tagCorrect := map[string]int64{}
-		for _, r := range rows {
-			if r.Tags == nil || *r.Tags == "" {
-				continue
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// countAnswersByTag zlicza odpowiedzi podanych userów per tag (bank "" = wszystkie).
+// Load answers + their questions' tags, then aggregate in Go.
+func countAnswersByTag(db *gorm.DB, uids []uint, bank string) (map[uint]map[string]*answerCount, error) {
+	out := make(map[uint]map[string]*answerCount, len(uids))
+	if len(uids) == 0 {
+		return out, nil
+	}
+	type AnsJoin struct {
+		UserID      uint
+		IsCorrect   bool
+		TimeSpentMs *int64
+		Tags        *string // CSV or JSON-ish string; we treat it as CSV "OMS,SOLR"
+	}
+	var rows []AnsJoin
+	tx := db.Table("answers a").
+		Select("e.user_id as user_id, a.is_correct as is_correct, a.time_spent_ms as time_spent_ms, q.tags as tags").
+		Joins("JOIN exams e ON e.id = a.exam_id").
+		Joins("JOIN questions q ON q.id = a.question_id").
+		Where("e.user_id IN ? AND e.status <> ?", uids, ExamStatusAbandoned)
+	if bank != "" {
+		tx = tx.Where("e.bank_id = ?", bank)
+	}
+	if err := tx.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		for _, tag := range splitTags(r.Tags) {
+			if out[r.UserID] == nil {
+				out[r.UserID] = map[string]*answerCount{}
 			}
-			// split CSV (e.g., "OMS, Backoffice")
-			parts := strings.Split(*r.Tags, ",")
-			seen := map[string]bool{}
-			for _, p := range parts {
-				tag := strings.TrimSpace(p)
-				if tag == "" || seen[tag] { continue }
-				seen[tag] = true
-				tagTotals[tag]++
-				if r.IsCorrect { tagCorrect[tag]++ }
+			tc := out[r.UserID][tag]
+			if tc == nil {
+				tc = &answerCount{}
+				out[r.UserID][tag] = tc
 			}
-		}
-		for tag, tot := range tagTotals {
-			resp.AnsweredByTag[tag] = tot
-			if tot > 0 {
-				resp.AccuracyByTag[tag] = float64(tagCorrect[tag]) * 100.0 / float64(tot)
+			tc.Total++
+			if r.IsCorrect {
+				tc.Correct++
+			}
+			if r.TimeSpentMs != nil {
+				tc.Timed++
+				tc.TimeMs += *r.TimeSpentMs
 			}
 		}
-
-		c.JSON(http.StatusOK, resp)
 	}
+	return out, nil
 }
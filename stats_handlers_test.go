@@ -0,0 +1,26 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitTags(t *testing.T) {
+	s := func(v string) *string { return &v }
+	tests := []struct {
+		name string
+		raw  *string
+		want []string
+	}{
+		{name: "nil", raw: nil, want: nil},
+		{name: "empty", raw: s(""), want: nil},
+		{name: "trim and dedupe", raw: s("OMS, Backoffice ,OMS,,"), want: []string{"OMS", "Backoffice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitTags(tt.raw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Czas na pytanie: klient zgłasza wejście na pytanie (POST .../view) albo sam
+// podaje timeSpentMs przy odpowiedzi. Każda odpowiedź zamyka jedną wizytę i dopisuje
+// jej czas do TimeSpentMs (jedynej) odpowiedzi na pytanie.
+
+// POST /api/v1/exams/:id/questions/:qid/view
+func ViewExamQuestion(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exam, ok := loadRunningExam(c, db)
+		if !ok || !rejectIfPaused(c, db, exam) {
+			return
+		}
+		now := time.Now()
+		res := db.Model(&ExamQuestion{}).
+			Where("exam_id = ? AND question_id = ?", exam.ID, c.Param("qid")).
+			Update("viewed_at", now)
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "question not in exam"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"viewedAt": now})
+	}
+}
+
+// measureTimeSpent zwraca czas wizyty kończącej się odpowiedzią: zgłoszony przez
+// klienta (obcięty do czasu egzaminu i do czasu od /view) albo od ostatniego /view. Zamyka wizytę.
+func measureTimeSpent(db *gorm.DB, exam *Exam, qid string, reportedMs *int64, now time.Time) (*int64, error) {
+	var eq ExamQuestion
+	if err := db.Where("exam_id = ? AND question_id = ?", exam.ID, qid).First(&eq).Error; err != nil {
+		return reportedTime(exam, reportedMs), nil // pytanie spoza egzaminu: tylko to, co podał klient
+	}
+	ms := reportedTime(exam, reportedMs)
+	if eq.ViewedAt != nil {
+		if v := now.Sub(*eq.ViewedAt).Milliseconds(); ms == nil || *ms > v {
+			ms = reportedTime(exam, &v)
+		}
+	}
+	if eq.ViewedAt != nil {
+		if err := db.Model(&eq).Update("viewed_at", nil).Error; err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+func reportedTime(exam *Exam, ms *int64) *int64 {
+	if ms == nil || *ms < 0 {
+		return nil
+	}
+	v := *ms
+	if limit := int64(exam.DurationSeconds) * 1000; limit > 0 && v > limit {
+		v = limit
+	}
+	return &v
+}
+
+// slowThresholdMs to budżet czasu na pytanie: czas egzaminu / liczba pytań.
+func slowThresholdMs(exam *Exam, questions int) int64 {
+	if questions == 0 {
+		return 0
+	}
+	return int64(exam.DurationSeconds) * 1000 / int64(questions)
+}
+
+type ExamTiming struct {
+	TotalTimeMs      int64    `json:"totalTimeMs"`
+	AvgTimeMs        *float64 `json:"avgTimeMs,omitempty"` // średnio na pytanie z pomiarem
+	TimedQuestions   int      `json:"timedQuestions"`
+	SlowThresholdMs  int64    `json:"slowThresholdMs"`
+	SlowAndWrong     int      `json:"slowAndWrong"`
+	SlowAndWrongList []string `json:"slowAndWrongQuestionIds"`
+}
+
+// summarizeTiming podsumowuje czasy z wierszy review jednego egzaminu.
+func summarizeTiming(review []ReviewRow, thresholdMs int64) ExamTiming {
+	t := ExamTiming{SlowThresholdMs: thresholdMs, SlowAndWrongList: []string{}}
+	for _, r := range review {
+		if r.TimeSpentMs == nil {
+			continue
+		}
+		t.TimedQuestions++
+		t.TotalTimeMs += *r.TimeSpentMs
+		if r.SlowAndWrong {
+			t.SlowAndWrong++
+			t.SlowAndWrongList = append(t.SlowAndWrongList, r.QuestionID)
+		}
+	}
+	if t.TimedQuestions > 0 {
+		avg := float64(t.TotalTimeMs) / float64(t.TimedQuestions)
+		t.AvgTimeMs = &avg
+	}
+	return t
+}
+
+// addTimeSpent sumuje czasy wizyt; nil = brak pomiaru.
+func addTimeSpent(a, b *int64) *int64 {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	v := *a + *b
+	return &v
+}
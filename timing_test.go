@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMeasureTimeSpent(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	viewed := now.Add(-80 * time.Second)
+	exam := Exam{ID: "e1", Type: "exam", StartedAt: now, DurationSeconds: 100}
+	db.Create(&exam)
+	db.Create(&ExamQuestion{ExamID: "e1", QuestionID: "q1", Position: 1, ViewedAt: &viewed})
+	db.Create(&ExamQuestion{ExamID: "e1", QuestionID: "q2", Position: 2})
+
+	ms, err := measureTimeSpent(db, &exam, "q1", nil, now)
+	if err != nil || ms == nil || *ms != 80000 {
+		t.Fatalf("measured %v (err %v), want 80000", ms, err)
+	}
+	var eq ExamQuestion
+	db.First(&eq, "exam_id = ? AND question_id = ?", "e1", "q1")
+	if eq.ViewedAt != nil {
+		t.Error("visit should be closed after answering")
+	}
+	// druga odpowiedź bez /view: brak pomiaru
+	if ms, _ := measureTimeSpent(db, &exam, "q1", nil, now); ms != nil {
+		t.Errorf("second answer without view measured %v", *ms)
+	}
+
+	// czas od klienta ma pierwszeństwo i jest obcinany do czasu egzaminu
+	reported := int64(500000)
+	if ms, _ := measureTimeSpent(db, &exam, "q2", &reported, now); ms == nil || *ms != 100000 {
+		t.Errorf("reported time not clamped: %v", ms)
+	}
+	negative := int64(-5)
+	if ms, _ := measureTimeSpent(db, &exam, "q2", &negative, now); ms != nil {
+		t.Errorf("negative reported time accepted: %v", *ms)
+	}
+}
+
+func TestBuildReviewFlagsSlowAndWrong(t *testing.T) {
+	db := newTestDB(t)
+	// 100 s na 2 pytania → budżet 50 s na pytanie
+	db.Create(&Exam{ID: "e1", Type: "exam", StartedAt: time.Now(), DurationSeconds: 100})
+	for i, id := range []string{"q1", "q2"} {
+		db.Create(&Question{ID: id, TextEN: id})
+		db.Create(&Option{QuestionID: id, OptionKey: "a", TextEN: "a", IsCorrect: true})
+		db.Create(&ExamQuestion{ExamID: "e1", QuestionID: id, Position: i + 1})
+	}
+	ms := func(v int64) *int64 { return &v }
+	// q1: dwie wizyty 30 s + 30 s (zsumowane w odpowiedzi), źle → wolno i źle; q2: 70 s, dobrze
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q1", SelectedRaw: `["b"]`, AnsweredAt: time.Now(), TimeSpentMs: addTimeSpent(ms(30000), ms(30000))})
+	db.Create(&Answer{ExamID: "e1", QuestionID: "q2", SelectedRaw: `["a"]`, IsCorrect: true, AnsweredAt: time.Now(), TimeSpentMs: ms(70000)})
+
+	review, _, err := buildReview(db, "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review[0].TimeSpentMs == nil || *review[0].TimeSpentMs != 60000 || !review[0].SlowAndWrong {
+		t.Errorf("q1 = %+v, want 60000 ms and slowAndWrong", review[0])
+	}
+	if review[1].SlowAndWrong {
+		t.Error("q2 was correct, must not be flagged")
+	}
+
+	timing := summarizeTiming(review, 50000)
+	if timing.TotalTimeMs != 130000 || *timing.AvgTimeMs != 65000 || timing.SlowAndWrong != 1 || timing.SlowAndWrongList[0] != "q1" {
+		t.Errorf("timing = %+v", timing)
+	}
+}
@@ -10,10 +10,33 @@ import (
 
 const cookieName = "sq_uid"
 
-// EnsureUser tworzy/odczytuje anonimowego usera przypisanego do cookie.
+// LoadUser odczytuje usera z cookie (jeśli istnieje), ale niczego nie tworzy.
+// Dzięki temu health checki, crawlery i skrypty curl nie zaśmiecają tabeli users.
+func LoadUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pubID, err := c.Cookie(cookieName)
+		if err == nil && pubID != "" {
+			var u User
+			if err := db.First(&u, "public_id = ?", pubID).Error; err == nil {
+				c.Set("userPublicID", pubID)
+				c.Set("userDBID", u.ID)
+				c.Set("userRole", u.Role)
+			}
+		}
+		c.Next()
+	}
+}
+
+// EnsureUser tworzy anonimowego usera leniwie — tylko na endpointach, które
+// faktycznie go potrzebują (zapis). Wymaga wcześniejszego LoadUser.
 // secureCookies ustaw na true w produkcji (HTTPS), w dev może być false.
 func EnsureUser(db *gorm.DB, secureCookies bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if _, ok := c.Get("userDBID"); ok {
+			c.Next()
+			return
+		}
+
 		pubID, err := c.Cookie(cookieName)
 		if err != nil || pubID == "" {
 			// brak cookie → utwórz usera i ustaw cookie
@@ -41,15 +64,12 @@ func EnsureUser(db *gorm.DB, secureCookies bool) gin.HandlerFunc {
 			return
 		}
 
-		// cookie jest → upewnij się, że user istnieje
-		var u User
-		if err := db.First(&u, "public_id = ?", pubID).Error; err != nil {
-			u = User{PublicID: pubID}
-			if err := db.Create(&u).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "user recreate failed"})
-				c.Abort()
-				return
-			}
+		// cookie jest, ale usera nie ma (np. usunięty przez cleanup) → odtwórz
+		u := User{PublicID: pubID}
+		if err := db.Create(&u).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "user recreate failed"})
+			c.Abort()
+			return
 		}
 
 		c.Set("userPublicID", pubID)
@@ -57,3 +77,53 @@ func EnsureUser(db *gorm.DB, secureCookies bool) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireRole przepuszcza userów z jedną z podanych ról; admin ma dostęp zawsze.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := currentUserID(c); !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+			c.Abort()
+			return
+		}
+		if !hasRole(c, roles...) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin przepuszcza tylko userów z rolą admin (ustawianą przez ADMIN_PUBLIC_IDS).
+func RequireAdmin() gin.HandlerFunc {
+	return RequireRole(RoleAdmin)
+}
+
+func hasRole(c *gin.Context, roles ...string) bool {
+	v, _ := c.Get("userRole")
+	role, _ := v.(string)
+	return role == RoleAdmin || (role != "" && containsString(roles, role))
+}
+
+func isAdmin(c *gin.Context) bool {
+	return hasRole(c, RoleAdmin)
+}
+
+// PromoteAdmins nadaje rolę admin userom o podanych publicId (ADMIN_PUBLIC_IDS).
+func PromoteAdmins(db *gorm.DB, publicIDs []string) error {
+	if len(publicIDs) == 0 {
+		return nil
+	}
+	return db.Model(&User{}).Where("public_id IN ?", publicIDs).Update("role", RoleAdmin).Error
+}
+
+// currentUserID zwraca ID usera ustawione przez LoadUser/EnsureUser.
+func currentUserID(c *gin.Context) (uint, bool) {
+	v, ok := c.Get("userDBID")
+	if !ok {
+		return 0, false
+	}
+	id, ok := v.(uint)
+	return id, ok
+}